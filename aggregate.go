@@ -16,7 +16,12 @@ limitations under the License.
 
 package errors
 
-import "strings"
+import (
+	"slices"
+	"strings"
+
+	"github.com/kubespress/errors/internal/errtree"
+)
 
 type errorAggregate struct {
 	errs []error
@@ -33,14 +38,15 @@ func (err ErrorList) Error() error {
 
 // Aggregate returns an error wrapping multiple other errors. If no other errors
 // are passed in this method returns nil.
+//
+// Aggregates passed in are flattened, so the aggregated errors are added rather
+// than the aggregate itself. As well as aggregates created by this package,
+// errors created by errors.Join in the standard library, go-multierror and
+// multierr are flattened. Aggregates that have been enriched or wrapped are
+// added as is, preserving their enrichments.
 func Aggregate(errs ...error) error {
-	// Filter out nil errors
-	filtered := make([]error, 0, len(errs))
-	for _, err := range errs {
-		if err != nil {
-			filtered = append(filtered, err)
-		}
-	}
+	// Filter out nil errors and flatten aggregates
+	filtered := appendAggregated(make([]error, 0, len(errs)), errs, nil)
 
 	// Output depends on the number of errors
 	switch len(filtered) {
@@ -60,6 +66,12 @@ func Aggregate(errs ...error) error {
 }
 
 func (e errorAggregate) Error() string {
+	return e.message(func(err error) string { return err.Error() })
+}
+
+// message joins the messages of the aggregated errors, the message of each
+// error is obtained using the provided function.
+func (e errorAggregate) message(fn func(error) string) string {
 	// Track seen errors and their message
 	seenerrs := map[string]struct{}{}
 	messages := make([]string, 0, len(e.errs))
@@ -69,11 +81,11 @@ func (e errorAggregate) Error() string {
 	case 0:
 		return ""
 	case 1:
-		return e.errs[0].Error()
+		return fn(e.errs[0])
 	default:
 		e.visit(func(err error) {
 			// Check to see if we have already seen this error
-			msg := err.Error()
+			msg := fn(err)
 			if _, seen := seenerrs[msg]; seen {
 				return
 			}
@@ -93,27 +105,10 @@ func (e errorAggregate) Error() string {
 	return "[" + strings.Join(messages, ", ") + "]"
 }
 
+// visit calls fn for each aggregated error, flattening nested aggregates
 func (e errorAggregate) visit(fn func(error)) {
-	type aggregate interface {
-		Errors() []error
-	}
-
-	// Loop over errors
-	for _, err := range e.errs {
-		switch err := err.(type) {
-		// If the error is another errorAggregate, recurse into its visit method
-		case errorAggregate:
-			err.visit(fn)
-
-		// If the error has an Errors() method, convert to a errorAggregate and
-		// visit all its errors. This allows it to work with similar libraries.
-		case aggregate:
-			errorAggregate{errs: err.Errors()}.visit(fn)
-
-		// Call the visit function on the error
-		default:
-			fn(err)
-		}
+	for _, err := range appendAggregated(nil, e.errs, nil) {
+		fn(err)
 	}
 }
 
@@ -124,3 +119,40 @@ func (e errorAggregate) Unwrap() []error {
 func (e errorAggregate) Errors() []error {
 	return e.Unwrap()
 }
+
+// appendAggregated appends the non-nil errors to results, replacing aggregates
+// with the errors they aggregate. ancestors are the identities of the
+// aggregates being flattened.
+func appendAggregated(results []error, errs []error, ancestors []any) []error {
+	for _, err := range errs {
+		// Aggregates deeper than the maximum depth, or that contain themselves,
+		// are added as is
+		nested, ok := errtree.Aggregated(err)
+		if !ok || len(ancestors) >= MaxDepth {
+			if err != nil {
+				results = append(results, err)
+			}
+			continue
+		}
+
+		key, identified := identity(err, nested)
+		if identified && slices.Contains(ancestors, key) {
+			results = append(results, err)
+			continue
+		}
+
+		results = appendAggregated(results, nested, append(ancestors, key))
+	}
+	return results
+}
+
+// branches returns the errors that make up err. Wrapped errors are followed
+// until an error wrapping multiple errors is found, each of these errors is
+// then expanded recursively. If there is no such error err itself is returned.
+func branches(err error) []error {
+	var results []error
+	for _, path := range LeafPaths(err) {
+		results = append(results, errtree.Branch(path))
+	}
+	return results
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package errors_test
 
 import (
+	stderrors "errors"
+	"fmt"
+
 	"github.com/kubespress/errors"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -66,4 +69,41 @@ var _ = Describe("Aggregate", func() {
 			Expect(errors.Is(err, err2)).To(BeTrue())
 		})
 	})
+
+	Context("with nested aggregates", func() {
+		var err1 = errors.New("example error 07")
+		var err2 = errors.New("example error 08")
+		var err3 = errors.New("example error 09")
+
+		It("should flatten the aggregates", func() {
+			err = errors.Aggregate(errors.Aggregate(err1, err2), stderrors.Join(err3, nil))
+			Expect(err).To(MatchError("[example error 07, example error 08, example error 09]"))
+			Expect(err.(interface{ Unwrap() []error }).Unwrap()).To(Equal([]error{err1, err2, err3}))
+		})
+
+		It("should return a single flattened error", func() {
+			Expect(errors.Aggregate(stderrors.Join(err1), nil)).To(Equal(err1))
+		})
+
+		It("should not flatten errors with their own message", func() {
+			wrapped := fmt.Errorf("%w and %w", err1, err2)
+			err = errors.Aggregate(wrapped, err3)
+			Expect(err).To(MatchError("[example error 07 and example error 08, example error 09]"))
+			Expect(err.(interface{ Unwrap() []error }).Unwrap()).To(Equal([]error{wrapped, err3}))
+		})
+
+		It("should visit the same errors that make up the message", func() {
+			err = errors.Aggregate(errors.Enrich(stderrors.Join(err1, err2), errors.Wrap("prefix")), err3)
+			Expect(err).To(MatchError("[prefix: example error 07\nexample error 08, example error 09]"))
+
+			var visited []error
+			errors.Visit(err, func(err error) bool {
+				if err == err1 || err == err2 || err == err3 {
+					visited = append(visited, err)
+				}
+				return true
+			})
+			Expect(visited).To(Equal([]error{err1, err2, err3}))
+		})
+	})
 })
@@ -0,0 +1,80 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/kubespress/errors"
+)
+
+// benchmarkError is a typical error, a sentinel wrapped with a message, an
+// enrichment and a stack
+var benchmarkError = errors.Enrich(stderrors.New("benchmark"),
+	errors.Wrap("message prefix"),
+	errors.Set[DiffDetail]("detail"),
+	errors.WithStack(),
+)
+
+func BenchmarkIs(b *testing.B) {
+	target := errors.New("target")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		errors.Is(benchmarkError, target)
+	}
+}
+
+func BenchmarkStdlibIs(b *testing.B) {
+	target := errors.New("target")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stderrors.Is(benchmarkError, target)
+	}
+}
+
+func BenchmarkAs(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var target *thirdPartyError
+		errors.As(benchmarkError, &target)
+	}
+}
+
+func BenchmarkStdlibAs(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var target *thirdPartyError
+		stderrors.As(benchmarkError, &target)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		errors.Get[DiffDetail](benchmarkError, "")
+	}
+}
+
+func BenchmarkIsAggregate(b *testing.B) {
+	target := errors.New("target")
+	err := errors.Aggregate(benchmarkError, errors.New("other"))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		errors.Is(err, target)
+	}
+}
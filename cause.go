@@ -0,0 +1,73 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import "github.com/kubespress/errors/internal/errtree"
+
+// causer is implemented by errors that declare whether they are the cause of
+// a failure or only wrap it
+type causer interface {
+	IsCause() bool
+}
+
+// Cause returns the original error, the innermost error found by following the
+// errors wrapped by err. Errors wrapping a single error, such as those created
+// by Enrich, WithStack, Wrap and Errorf, are skipped. Errors wrapping multiple
+// errors are returned as is, Causes can be used to find the causes of each of
+// them.
+//
+// Errors that wrap another error but are meaningful in their own right can
+// declare themselves as the cause by implementing an IsCause() bool method that
+// returns true, in which case the error wrapped is not followed.
+func Cause(err error) error {
+	for depth := 0; err != nil && depth < MaxDepth; depth++ {
+		if isCause(err) {
+			return err
+		}
+
+		nested := unwrap(err)
+		if nested == nil {
+			return err
+		}
+		err = nested
+	}
+
+	return err
+}
+
+// Causes returns the original errors, see Cause. Unlike Cause, aggregates are
+// followed, so the cause of each aggregated error is returned. Other errors
+// wrapping multiple errors, such as those created by Errorf, are a single
+// cause as they are for Cause.
+func Causes(err error) []error {
+	var causes []error
+	_ = Walk(err, func(node WalkNode) error {
+		if _, ok := errtree.Aggregated(node.Err); isCause(node.Err) || !ok && unwrap(node.Err) == nil {
+			causes = append(causes, node.Err)
+			return SkipSubtree
+		}
+		return nil
+	})
+
+	return causes
+}
+
+// isCause returns true if the error declares itself as a cause
+func isCause(err error) bool {
+	c, ok := err.(causer)
+	return ok && c.IsCause()
+}
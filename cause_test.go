@@ -0,0 +1,85 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	stderrors "errors"
+	"fmt"
+
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// causeError wraps another error, but declares itself as the cause
+type causeError struct {
+	err error
+}
+
+func (err causeError) Error() string { return "cause: " + err.err.Error() }
+func (err causeError) Unwrap() error { return err.err }
+func (err causeError) IsCause() bool { return true }
+
+var _ = Describe("Cause", func() {
+	var sentinel1 = errors.New("test message 31")
+	var sentinel2 = errors.New("test message 32")
+
+	DescribeTable("should return the original error",
+		func(err, expected error) {
+			Expect(errors.Cause(err)).To(Equal(expected))
+		},
+		Entry("plain error", sentinel1, sentinel1),
+		Entry("enriched error", errors.Enrich(sentinel1, errors.Set[DiffDetail]("detail"), errors.WithStack(), errors.Wrap("prefix")), sentinel1),
+		Entry("fmt wrapped error", fmt.Errorf("prefix: %w", errors.Errorf("inner: %w", sentinel1)), sentinel1),
+		Entry("declared cause", errors.Enrich(causeError{sentinel1}, errors.Wrap("prefix")), causeError{sentinel1}),
+		Entry("self-referential error", &selfReferentialError{}, &selfReferentialError{}),
+	)
+
+	It("should return nil for nil errors", func() {
+		Expect(errors.Cause(nil)).To(BeNil())
+	})
+
+	It("should return aggregates as is", func() {
+		aggregate := errors.Aggregate(sentinel1, sentinel2)
+		Expect(errors.Cause(errors.Enrich(aggregate, errors.Wrap("prefix")))).To(Equal(aggregate))
+	})
+})
+
+var _ = Describe("Causes", func() {
+	var sentinel1 = errors.New("test message 31")
+	var sentinel2 = errors.New("test message 32")
+	var sentinel3 = errors.New("test message 33")
+	var formatted = fmt.Errorf("%w and %w", sentinel1, sentinel2)
+	var copied = errors.Errorf("copy %w to %w", sentinel1, sentinel2)
+
+	DescribeTable("should return the original errors",
+		func(err error, expected []error) {
+			Expect(errors.Causes(err)).To(Equal(expected))
+		},
+		Entry("nil error", nil, []error(nil)),
+		Entry("plain error", sentinel1, []error{sentinel1}),
+		Entry("enriched error", errors.Enrich(sentinel1, errors.WithStack(), errors.Wrap("prefix")), []error{sentinel1}),
+		Entry("aggregate", errors.Enrich(errors.Aggregate(
+			errors.Enrich(sentinel1, errors.Wrap("prefix")),
+			errors.Aggregate(sentinel2, causeError{sentinel3}),
+		), errors.Wrap("prefix")), []error{sentinel1, sentinel2, causeError{sentinel3}}),
+		Entry("joined errors", stderrors.Join(sentinel1, sentinel2), []error{sentinel1, sentinel2}),
+		Entry("formatted errors wrapping multiple errors", formatted, []error{formatted}),
+		Entry("errors formatted by Errorf", copied, []error{copied}),
+		Entry("errors with an Errors method", recursiveAggregate{sentinel1, sentinel2}, []error{sentinel1, sentinel2}),
+	)
+})
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"reflect"
+	"sync"
+)
+
+var classifiers = struct {
+	sync.RWMutex
+	funcs map[reflect.Type][]any
+}{
+	funcs: map[reflect.Type][]any{},
+}
+
+// RegisterClassifier registers a function that derives an enrichment from
+// errors that have not been enriched, such as errors returned by third party
+// libraries. Get and Check fall back to the registered classifiers when the
+// error does not contain the enrichment. Each classifier is called on every
+// error in the chain, from the outermost error inward, the first value found
+// is used. Classifiers are called in the order they are registered.
+//
+// Classifiers are typically registered in an init function:
+//
+//	func init() {
+//		errors.RegisterClassifier(func(err error) (errors.Category, bool) {
+//			if err, ok := err.(*pq.Error); ok && err.Code == "23505" {
+//				return errors.CategoryConflict, true
+//			}
+//			return "", false
+//		})
+//	}
+func RegisterClassifier[T any](fn func(error) (T, bool)) {
+	classifiers.Lock()
+	defer classifiers.Unlock()
+
+	key := classifierKey[T]()
+	classifiers.funcs[key] = append(classifiers.funcs[key], fn)
+}
+
+// lookup returns the enrichment of type T, if the error does not contain the
+// enrichment the registered classifiers are used.
+func lookup[T any](err error) (T, bool) {
+	// Check if error has enrichment
+	var result T
+	if searchChain(err, func(err error) bool {
+		enriched, ok := err.(enrichedError[T])
+		if ok {
+			result = enriched.enrichment
+		}
+		return ok
+	}) {
+		return result, true
+	}
+
+	return classifyAs[T](err)
+}
+
+// classifyAs calls the classifiers registered for T on each error in the chain
+func classifyAs[T any](err error) (result T, found bool) {
+	if err == nil {
+		return result, false
+	}
+
+	// Copy the classifiers so the lock is not held while calling them
+	classifiers.RLock()
+	funcs := classifiers.funcs[classifierKey[T]()]
+	classifiers.RUnlock()
+
+	if len(funcs) == 0 {
+		return result, false
+	}
+
+	found = searchChain(err, func(err error) bool {
+		for _, fn := range funcs {
+			if value, ok := fn.(func(error) (T, bool))(err); ok {
+				result = value
+				return true
+			}
+		}
+		return false
+	})
+
+	return result, found
+}
+
+func classifierKey[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
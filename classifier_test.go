@@ -0,0 +1,103 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"fmt"
+
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// thirdPartyError simulates an error returned by a third party library
+type thirdPartyError struct {
+	code string
+}
+
+func (err *thirdPartyError) Error() string { return "third party error " + err.code }
+
+// Throttled indicates the error was caused by throttling
+type Throttled bool
+
+// Code is the code of an error
+type Code string
+
+func init() {
+	errors.RegisterClassifier(func(err error) (errors.Category, bool) {
+		if err, ok := err.(*thirdPartyError); ok && err.code == "23505" {
+			return errors.CategoryConflict, true
+		}
+		return errors.CategoryUnknown, false
+	})
+
+	errors.RegisterClassifier(func(err error) (Throttled, bool) {
+		if err, ok := err.(*thirdPartyError); ok && err.code == "throttled" {
+			return true, true
+		}
+		return false, false
+	})
+
+	errors.RegisterClassifier(func(err error) (Code, bool) {
+		if err, ok := err.(*thirdPartyError); ok {
+			return Code(err.code), true
+		}
+		return "", false
+	})
+
+	errors.RegisterClassifier(func(err error) (Code, bool) {
+		return "second", true
+	})
+}
+
+var _ = Describe("RegisterClassifier", func() {
+	It("should be used by Classify", func() {
+		err := fmt.Errorf("prefix: %w", &thirdPartyError{code: "23505"})
+		Expect(errors.Classify(err)).To(Equal(errors.CategoryConflict))
+		Expect(errors.Classify(&thirdPartyError{code: "other"})).To(Equal(errors.CategoryUnknown))
+	})
+
+	It("should be used by Check", func() {
+		Expect(errors.Check[Throttled](&thirdPartyError{code: "throttled"})).To(BeTrue())
+		Expect(errors.Check[Throttled](&thirdPartyError{code: "other"})).To(BeFalse())
+		Expect(errors.Check[Throttled](nil)).To(BeFalse())
+	})
+
+	It("should prefer enrichments", func() {
+		err := errors.Enrich(&thirdPartyError{code: "throttled"}, errors.Set[Throttled](false))
+		Expect(errors.Check[Throttled](err)).To(BeFalse())
+
+		err = errors.Enrich(&thirdPartyError{code: "23505"}, errors.SetCategory(errors.CategoryInternal))
+		Expect(errors.Classify(err)).To(Equal(errors.CategoryInternal))
+	})
+
+	It("should use the outermost error", func() {
+		err := errors.Enrich(&thirdPartyError{code: "inner"}, errors.Wrap("prefix"))
+		Expect(errors.Get[Code](err, "default")).To(Equal(Code("second")))
+
+		err = errors.Aggregate(&thirdPartyError{code: "first"}, &thirdPartyError{code: "second"})
+		Expect(errors.Get[Code](err, "default")).To(Equal(Code("second")))
+	})
+
+	It("should call classifiers in registration order", func() {
+		Expect(errors.Get[Code](&thirdPartyError{code: "first"}, "default")).To(Equal(Code("first")))
+	})
+
+	It("should return the default if nothing is found", func() {
+		Expect(errors.Get[Code](nil, "default")).To(Equal(Code("default")))
+	})
+})
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"reflect"
+)
+
+// Category is the classification of an error. The built-in categories are
+// defined as constants, additional categories can be defined by converting a
+// string.
+type Category string
+
+const (
+	// CategoryUnknown is returned when an error could not be classified
+	CategoryUnknown Category = "Unknown"
+	// CategoryNotFound indicates a requested resource does not exist
+	CategoryNotFound Category = "NotFound"
+	// CategoryAlreadyExists indicates a resource being created already exists
+	CategoryAlreadyExists Category = "AlreadyExists"
+	// CategoryConflict indicates the operation conflicts with the current
+	// state, for example a concurrent modification
+	CategoryConflict Category = "Conflict"
+	// CategoryUnavailable indicates a dependency is temporarily unavailable
+	CategoryUnavailable Category = "Unavailable"
+	// CategoryTimeout indicates the operation did not complete in time
+	CategoryTimeout Category = "Timeout"
+	// CategoryCanceled indicates the operation was canceled by the caller
+	CategoryCanceled Category = "Canceled"
+	// CategoryInvalidArgument indicates the input to the operation is invalid
+	CategoryInvalidArgument Category = "InvalidArgument"
+	// CategoryPermissionDenied indicates the caller is not permitted to
+	// perform the operation
+	CategoryPermissionDenied Category = "PermissionDenied"
+	// CategoryUnauthenticated indicates the caller could not be identified
+	CategoryUnauthenticated Category = "Unauthenticated"
+	// CategoryResourceExhausted indicates a quota or rate limit was exceeded
+	CategoryResourceExhausted Category = "ResourceExhausted"
+	// CategoryInternal indicates a bug or broken invariant
+	CategoryInternal Category = "Internal"
+)
+
+// Retryable returns true if errors of this category are typically transient,
+// meaning the operation may succeed if retried.
+func (c Category) Retryable() bool {
+	switch c {
+	case CategoryUnavailable, CategoryTimeout, CategoryResourceExhausted, CategoryConflict:
+		return true
+	default:
+		return false
+	}
+}
+
+// Retryable indicates if an error is transient and the operation may succeed
+// if retried. It overrides the default derived from the category of the error.
+type Retryable bool
+
+// SetCategory enriches an error with a category
+func SetCategory(category Category) Enricher {
+	return Set(category)
+}
+
+// Classify returns the category of the error. The outermost category the
+// error was enriched with is returned, otherwise the error is classified using
+// the registered classifiers, see RegisterClassifier. Errors from
+// the standard library, such as os.ErrNotExist, context.DeadlineExceeded,
+// net.Error timeouts and syscall errnos are classified automatically. If no
+// category is found CategoryUnknown is returned.
+func Classify(err error) Category {
+	return Get(err, CategoryUnknown)
+}
+
+// IsRetryable returns true if the error is transient. The Retryable enrichment
+// is used if present, otherwise this is derived from the category of the error.
+func IsRetryable(err error) bool {
+	if retryable, ok := lookup[Retryable](err); ok {
+		return bool(retryable)
+	}
+
+	return Classify(err).Retryable()
+}
+
+func init() {
+	RegisterClassifier(classify)
+}
+
+// classify returns the category of standard library errors, it is registered as
+// a classifier so it is used by Classify and Get
+func classify(err error) (Category, bool) {
+	// Standard library sentinels, these are matched using the Is method of
+	// the error as well so errors such as syscall.Errno are recognised
+	for _, sentinel := range []struct {
+		err      error
+		category Category
+	}{
+		{context.Canceled, CategoryCanceled},
+		{context.DeadlineExceeded, CategoryTimeout},
+		{os.ErrDeadlineExceeded, CategoryTimeout},
+		{fs.ErrNotExist, CategoryNotFound},
+		{fs.ErrExist, CategoryAlreadyExists},
+		{fs.ErrPermission, CategoryPermissionDenied},
+		{fs.ErrInvalid, CategoryInvalidArgument},
+	} {
+		if matches(err, sentinel.err) {
+			return sentinel.category, true
+		}
+	}
+
+	// Operating system errors
+	if category, ok := classifyErrno(err); ok {
+		return category, true
+	}
+
+	// Errors reporting a timeout, such as net.Error
+	if timeout, ok := err.(interface{ Timeout() bool }); ok && timeout.Timeout() {
+		return CategoryTimeout, true
+	}
+
+	return CategoryUnknown, false
+}
+
+// matches returns true if err is target, or reports being target through its
+// Is method. Unlike Is the error is not unwrapped. As with the standard library
+// errors are only compared if the target is comparable, the Is method is always
+// consulted.
+func matches(err, target error) bool {
+	if target != nil && reflect.TypeOf(target).Comparable() && same(err, target) {
+		return true
+	}
+
+	if is, ok := err.(interface{ Is(error) bool }); ok && is.Is(target) {
+		return true
+	}
+
+	return false
+}
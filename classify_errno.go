@@ -0,0 +1,44 @@
+//go:build !plan9
+
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import "syscall"
+
+// classifyErrno classifies operating system errors not covered by the
+// standard library sentinels
+func classifyErrno(err error) (Category, bool) {
+	errno, ok := err.(syscall.Errno)
+	if !ok {
+		return CategoryUnknown, false
+	}
+
+	switch errno {
+	case syscall.ECONNREFUSED, syscall.ECONNRESET, syscall.ECONNABORTED,
+		syscall.EHOSTUNREACH, syscall.ENETUNREACH, syscall.ENETDOWN, syscall.EPIPE:
+		return CategoryUnavailable, true
+	case syscall.ETIMEDOUT:
+		return CategoryTimeout, true
+	case syscall.EAGAIN, syscall.EBUSY, syscall.ENOSPC, syscall.EMFILE, syscall.ENFILE:
+		return CategoryResourceExhausted, true
+	case syscall.EINVAL:
+		return CategoryInvalidArgument, true
+	default:
+		return CategoryUnknown, false
+	}
+}
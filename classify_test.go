@@ -0,0 +1,70 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Classify", func() {
+	var sentinel = errors.New("test message 01")
+
+	DescribeTable("should classify errors",
+		func(err error, expected errors.Category) {
+			Expect(errors.Classify(err)).To(Equal(expected))
+		},
+		Entry("nil error", nil, errors.CategoryUnknown),
+		Entry("plain error", sentinel, errors.CategoryUnknown),
+		Entry("enriched error", errors.Enrich(sentinel, errors.SetCategory(errors.CategoryConflict)), errors.CategoryConflict),
+		Entry("wrapped enriched error", errors.Enrich(sentinel, errors.SetCategory(errors.CategoryConflict), errors.Wrap("prefix")), errors.CategoryConflict),
+		Entry("outermost category", errors.Enrich(sentinel, errors.SetCategory(errors.CategoryConflict), errors.SetCategory(errors.CategoryInternal)), errors.CategoryInternal),
+		Entry("custom category", errors.Enrich(sentinel, errors.SetCategory("Custom")), errors.Category("Custom")),
+		Entry("os.ErrNotExist", errors.Enrich(os.ErrNotExist, errors.Wrap("prefix")), errors.CategoryNotFound),
+		Entry("os.ErrExist", os.ErrExist, errors.CategoryAlreadyExists),
+		Entry("os.ErrPermission", os.ErrPermission, errors.CategoryPermissionDenied),
+		Entry("path error", &os.PathError{Op: "open", Path: "/test", Err: syscall.ENOENT}, errors.CategoryNotFound),
+		Entry("context.Canceled", fmt.Errorf("prefix: %w", context.Canceled), errors.CategoryCanceled),
+		Entry("context.DeadlineExceeded", context.DeadlineExceeded, errors.CategoryTimeout),
+		Entry("os.ErrDeadlineExceeded", os.ErrDeadlineExceeded, errors.CategoryTimeout),
+		Entry("net.Error timeout", &net.DNSError{Err: "test message 02", IsTimeout: true}, errors.CategoryTimeout),
+		Entry("syscall.ECONNREFUSED", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, errors.CategoryUnavailable),
+		Entry("syscall.EACCES", syscall.EACCES, errors.CategoryPermissionDenied),
+		Entry("category overrides standard library", errors.Enrich(os.ErrNotExist, errors.SetCategory(errors.CategoryInternal)), errors.CategoryInternal),
+		Entry("aggregate", errors.Aggregate(sentinel, os.ErrNotExist), errors.CategoryNotFound),
+	)
+
+	DescribeTable("should determine if errors are retryable",
+		func(err error, expected bool) {
+			Expect(errors.IsRetryable(err)).To(Equal(expected))
+		},
+		Entry("nil error", nil, false),
+		Entry("plain error", sentinel, false),
+		Entry("unavailable", errors.Enrich(sentinel, errors.SetCategory(errors.CategoryUnavailable)), true),
+		Entry("timeout", context.DeadlineExceeded, true),
+		Entry("not found", os.ErrNotExist, false),
+		Entry("retryable enrichment", errors.Enrich(sentinel, errors.Set[errors.Retryable](true)), true),
+		Entry("retryable enrichment overrides category", errors.Enrich(context.DeadlineExceeded, errors.Set[errors.Retryable](false)), false),
+	)
+})
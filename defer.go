@@ -0,0 +1,63 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+// Target is where Defer and Capture store errors, either a pointer to an error
+// such as a named return value, or an ErrorCollector.
+type Target interface {
+	*error | *ErrorCollector
+}
+
+// Defer calls the function and merges any error it returns into the target,
+// enriching it with the provided enrichments. It is designed to be deferred,
+// so errors from functions such as Close are not lost, for example:
+//
+//	func write(path string) (err error) {
+//		f, err := os.Create(path)
+//		if err != nil {
+//			return err
+//		}
+//		defer errors.Defer(&err, f.Close, errors.Wrap("failed to close file"))
+//		...
+//	}
+//
+// If the target already contains an error both errors are aggregated, so Is
+// matches either of them.
+func Defer[T Target](target T, fn func() error, enrichments ...Enricher) {
+	Capture(target, fn, enrichments...)
+}
+
+// Capture calls the function and merges any error it returns into the target,
+// enriching it with the provided enrichments. It is the same as Defer, and
+// reads better when the call is not deferred, for example:
+//
+//	errors.Capture(&err, w.Flush, errors.Wrap("failed to flush"))
+func Capture[T Target](target T, fn func() error, enrichments ...Enricher) {
+	// Enrich the error, the enrichments may drop it
+	err := Enrich(fn(), enrichments...)
+	if err == nil {
+		return
+	}
+
+	// Merge the error into the target
+	switch target := any(target).(type) {
+	case *error:
+		*target = Aggregate(*target, err)
+	case *ErrorCollector:
+		target.AppendErrorIfNotNil(err)
+	}
+}
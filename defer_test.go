@@ -0,0 +1,113 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Defer", func() {
+	var primary = errors.New("test message 01")
+	var closeErr = errors.New("test message 02")
+
+	closer := func(err error) func() error {
+		return func() error { return err }
+	}
+
+	run := func(result, deferred error, enrichments ...errors.Enricher) (err error) {
+		defer errors.Defer(&err, closer(deferred), enrichments...)
+		return result
+	}
+
+	It("should do nothing if there are no errors", func() {
+		Expect(run(nil, nil)).ToNot(HaveOccurred())
+	})
+
+	It("should return the primary error", func() {
+		Expect(run(primary, nil)).To(Equal(primary))
+	})
+
+	It("should return the deferred error", func() {
+		err := run(nil, closeErr, errors.Wrap("failed to close"))
+		Expect(err).To(MatchError("failed to close: test message 02"))
+		Expect(errors.Is(err, closeErr)).To(BeTrue())
+	})
+
+	It("should aggregate both errors", func() {
+		err := run(primary, closeErr, errors.Wrap("failed to close"))
+		Expect(err).To(MatchError("[test message 01, failed to close: test message 02]"))
+		Expect(errors.Is(err, primary)).To(BeTrue())
+		Expect(errors.Is(err, closeErr)).To(BeTrue())
+	})
+
+	It("should respect enrichments that drop the error", func() {
+		ignore := func(error) error { return nil }
+		err := run(primary, closeErr, ignore)
+		Expect(err).To(Equal(primary))
+	})
+
+	It("should append to an error collector", func() {
+		collector := errors.NewErrorCollector()
+		errors.Defer(collector, closer(nil))
+		errors.Defer(collector, closer(closeErr), errors.Wrap("failed to close"))
+		errors.Capture(collector, closer(primary))
+
+		Expect(collector.ErrorList).To(HaveLen(2))
+		Expect(collector.Error()).To(MatchError("[failed to close: test message 02, test message 01]"))
+	})
+})
+
+var _ = Describe("Capture", func() {
+	var flushErr = errors.New("test message 02")
+	var calls int
+
+	flush := func(err error) func() error {
+		return func() error {
+			calls++
+			return err
+		}
+	}
+
+	BeforeEach(func() {
+		calls = 0
+	})
+
+	It("should merge the error into the target", func() {
+		var err error
+		errors.Capture(&err, flush(nil))
+		Expect(err).ToNot(HaveOccurred())
+
+		errors.Capture(&err, flush(errors.New("test message 01")))
+		errors.Capture(&err, flush(flushErr), errors.Wrap("prefix"))
+		Expect(err).To(MatchError("[test message 01, prefix: test message 02]"))
+		Expect(errors.Is(err, flushErr)).To(BeTrue())
+		Expect(calls).To(Equal(3))
+	})
+
+	It("should call the function when the deferred call runs", func() {
+		run := func() (err error) {
+			defer errors.Capture(&err, flush(flushErr))
+			Expect(calls).To(Equal(0))
+			return nil
+		}
+
+		Expect(run()).To(Equal(flushErr))
+		Expect(calls).To(Equal(1))
+	})
+})
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/kubespress/errors/internal/errtree"
+)
+
+// Diff structurally compares two error trees, returning a readable description
+// of the differences, one per line. An empty string is returned if the trees
+// are equivalent. The trees are compared by:
+//
+//   - The messages added by wrapping errors, such as those added using Wrap
+//   - The outermost enrichment of each type, compared using reflect.DeepEqual
+//   - The identity of the innermost errors, using their Is method if they have
+//     one, or their type and message if they are not comparable
+//   - The errors within aggregates, compared in order
+//
+// Each difference is prefixed with its path within the tree, for example
+// "error[1].wrap[0]" is the outermost wrap of the second error within an
+// aggregate. Stacks are not compared.
+func Diff(a, b error) string {
+	var diffs []string
+	diffTrees(&diffs, "error", newDiffNode(a, 0, nil), newDiffNode(b, 0, nil))
+	return strings.Join(diffs, "\n")
+}
+
+// diffNode is the comparable representation of a branch of an error tree
+type diffNode struct {
+	wraps       []string
+	enrichments map[string]any
+	leaf        error
+	multi       string
+	children    []*diffNode
+}
+
+// newDiffNode builds the node for the error, ancestors are the identities of
+// the errors wrapping multiple errors above it
+func newDiffNode(err error, depth int, ancestors []any) *diffNode {
+	node := &diffNode{enrichments: map[string]any{}}
+	for ; err != nil && depth <= MaxDepth; depth++ {
+		// Errors wrapping multiple errors, including aggregates that also
+		// implement Unwrap() error
+		if errtree.Multiple(err) {
+			// Errors that contain themselves are compared as leaves
+			children := errtree.Children(err)
+			key, identified := identity(err, children)
+			if identified && slices.Contains(ancestors, key) {
+				node.leaf = err
+				return node
+			}
+
+			node.multi = fmt.Sprintf("%T", err)
+			for _, child := range children {
+				if child != nil {
+					node.children = append(node.children, newDiffNode(child, depth+1, append(ancestors, key)))
+				}
+			}
+			return node
+		}
+
+		switch e := err.(type) {
+		case interface{ Enrichment() any }:
+			// Only the outermost enrichment of each type is compared, as this
+			// is the value returned by Get
+			name := fmt.Sprintf("%T", e.Enrichment())
+			if _, ok := node.enrichments[name]; !ok {
+				node.enrichments[name] = e.Enrichment()
+			}
+			err = unwrap(err)
+		case interface{ Callers() []uintptr }:
+			err = unwrap(err)
+		case interface{ Unwrap() error }:
+			nested := e.Unwrap()
+			if nested == nil {
+				node.leaf = err
+				return node
+			}
+
+			// Record the message added by the wrapping error
+			msg, ok := strings.CutSuffix(err.Error(), ": "+nested.Error())
+			if !ok {
+				msg = err.Error()
+			}
+			node.wraps = append(node.wraps, msg)
+			err = nested
+		default:
+			node.leaf = err
+			return node
+		}
+	}
+
+	return node
+}
+
+func diffTrees(diffs *[]string, path string, a, b *diffNode) {
+	report := func(path, format string, args ...any) {
+		*diffs = append(*diffs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	// Compare the wrap messages
+	for i := 0; i < len(a.wraps) || i < len(b.wraps); i++ {
+		wrapPath := fmt.Sprintf("%s.wrap[%d]", path, i)
+		switch {
+		case i >= len(b.wraps):
+			report(wrapPath, "%q != <none>", a.wraps[i])
+		case i >= len(a.wraps):
+			report(wrapPath, "<none> != %q", b.wraps[i])
+		case a.wraps[i] != b.wraps[i]:
+			report(wrapPath, "%q != %q", a.wraps[i], b.wraps[i])
+		}
+	}
+
+	// Compare the enrichments, sorted by type so the output is stable
+	names := make([]string, 0, len(a.enrichments)+len(b.enrichments))
+	for name := range a.enrichments {
+		names = append(names, name)
+	}
+	for name := range b.enrichments {
+		if _, ok := a.enrichments[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		enrichmentPath := fmt.Sprintf("%s.enrichment(%s)", path, name)
+		valueA, okA := a.enrichments[name]
+		valueB, okB := b.enrichments[name]
+		switch {
+		case !okB:
+			report(enrichmentPath, "%#v != <none>", valueA)
+		case !okA:
+			report(enrichmentPath, "<none> != %#v", valueB)
+		case !reflect.DeepEqual(valueA, valueB):
+			report(enrichmentPath, "%#v != %#v", valueA, valueB)
+		}
+	}
+
+	// Compare aggregates
+	if a.multi != "" || b.multi != "" {
+		if a.multi == "" || b.multi == "" {
+			report(path, "%s != %s", describeDiffNode(a), describeDiffNode(b))
+			return
+		}
+
+		for i := 0; i < len(a.children) || i < len(b.children); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(b.children):
+				report(childPath, "%s != <none>", describeDiffNode(a.children[i]))
+			case i >= len(a.children):
+				report(childPath, "<none> != %s", describeDiffNode(b.children[i]))
+			default:
+				diffTrees(diffs, childPath, a.children[i], b.children[i])
+			}
+		}
+		return
+	}
+
+	// Compare the innermost errors
+	if !sameLeaf(a.leaf, b.leaf) {
+		report(path+".cause", "%s != %s", describeLeaf(a.leaf), describeLeaf(b.leaf))
+	}
+}
+
+// sameLeaf returns true if the errors are identical, or either reports being
+// the other through its Is method. Errors that are not comparable are the same
+// if they have the same type and message.
+func sameLeaf(a, b error) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	if matches(a, b) || matches(b, a) {
+		return true
+	}
+
+	if !reflect.TypeOf(a).Comparable() && !reflect.TypeOf(b).Comparable() {
+		return reflect.TypeOf(a) == reflect.TypeOf(b) && a.Error() == b.Error()
+	}
+
+	return false
+}
+
+func describeDiffNode(node *diffNode) string {
+	if node.multi != "" {
+		return fmt.Sprintf("%s with %d errors", node.multi, len(node.children))
+	}
+	return describeLeaf(node.leaf)
+}
+
+func describeLeaf(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	if id, ok := SentinelID(err); ok {
+		return fmt.Sprintf("%T %q (%s)", err, err.Error(), id)
+	}
+	return fmt.Sprintf("%T %q", err, err.Error())
+}
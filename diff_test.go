@@ -0,0 +1,90 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"fmt"
+
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// DiffDetail and DiffFlag are enrichments used to test Diff
+type (
+	DiffDetail string
+	DiffFlag   bool
+)
+
+var _ = Describe("Diff", func() {
+	var sentinel1 = errors.New("test message 11")
+	var sentinel2 = errors.New("test message 12")
+
+	DescribeTable("should report no differences for equivalent errors",
+		func(a, b error) {
+			Expect(errors.Diff(a, b)).To(BeEmpty())
+		},
+		Entry("nil errors", nil, nil),
+		Entry("identical sentinels", sentinel1, sentinel1),
+		Entry("wrapped sentinels",
+			errors.Enrich(sentinel1, errors.Wrapf("failed %d", 1), errors.WithStack()),
+			errors.Enrich(sentinel1, errors.Wrap("failed 1")),
+		),
+		Entry("fmt wrapped sentinels", fmt.Errorf("failed: %w", sentinel1), errors.Enrich(sentinel1, errors.Wrap("failed"))),
+		Entry("enrichments",
+			errors.Enrich(sentinel1, errors.Set[DiffDetail]("inner"), errors.Set[DiffDetail]("outer")),
+			errors.Enrich(sentinel1, errors.Set[DiffDetail]("outer")),
+		),
+		Entry("aggregates",
+			errors.Aggregate(sentinel1, errors.Enrich(sentinel2, errors.Wrap("prefix"))),
+			errors.Aggregate(sentinel1, errors.Enrich(sentinel2, errors.Wrap("prefix"))),
+		),
+		Entry("uncomparable errors", uncomparableError{"a"}, uncomparableError{"b"}),
+	)
+
+	DescribeTable("should report differences",
+		func(a, b error, expected string) {
+			Expect(errors.Diff(a, b)).To(Equal(expected))
+		},
+		Entry("nil error", nil, sentinel1, `error.cause: <nil> != errors.errorString "test message 11"`),
+		Entry("different sentinels", sentinel1, sentinel2, `error.cause: errors.errorString "test message 11" != errors.errorString "test message 12"`),
+		Entry("different wraps",
+			errors.Enrich(sentinel1, errors.Wrap("inner"), errors.Wrap("outer")),
+			errors.Enrich(sentinel1, errors.Wrap("other")),
+			"error.wrap[0]: \"outer\" != \"other\"\nerror.wrap[1]: \"inner\" != <none>",
+		),
+		Entry("different enrichments",
+			errors.Enrich(sentinel1, errors.Set[DiffDetail]("value 1"), errors.SetCategory(errors.CategoryConflict)),
+			errors.Enrich(sentinel1, errors.Set[DiffDetail]("value 2"), errors.Set[DiffFlag](true)),
+			"error.enrichment(errors.Category): \"Conflict\" != <none>\n"+
+				"error.enrichment(errors_test.DiffDetail): \"value 1\" != \"value 2\"\n"+
+				"error.enrichment(errors_test.DiffFlag): <none> != true",
+		),
+		Entry("different aggregates",
+			errors.Aggregate(sentinel1, errors.Enrich(sentinel2, errors.Wrap("prefix"))),
+			errors.Aggregate(sentinel1, sentinel1, sentinel2),
+			"error[1].wrap[0]: \"prefix\" != <none>\n"+
+				"error[1].cause: errors.errorString \"test message 12\" != errors.errorString \"test message 11\"\n"+
+				"error[2]: <none> != errors.errorString \"test message 12\"",
+		),
+		Entry("aggregate and single error",
+			errors.Aggregate(sentinel1, sentinel2),
+			sentinel1,
+			`error: errors.errorAggregate with 2 errors != errors.errorString "test message 11"`,
+		),
+	)
+})
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errcompat converts aggregated errors between this module and
+// errors.Join in the standard library, go-multierror and multierr.
+//
+// Converting is rarely required, errors.Aggregate flattens aggregates from
+// each of these libraries and enrichments within them are found by errors.Get
+// and errors.Check. It is useful when passing errors to code that expects a
+// specific aggregate type.
+package errcompat
+
+import (
+	stderrors "errors"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kubespress/errors"
+	"go.uber.org/multierr"
+)
+
+// ToJoin converts the error into an error created using errors.Join in the
+// standard library, see Split. Nil is returned for nil errors.
+func ToJoin(err error) error {
+	return stderrors.Join(Split(err)...)
+}
+
+// FromJoin converts an error created using errors.Join in the standard library
+// into an aggregate created by errors.Aggregate.
+func FromJoin(err error) error {
+	return errors.Aggregate(err)
+}
+
+// ToMultierror converts the error into a go-multierror error, see Split. Nil
+// is returned for nil errors, ErrorOrNil can be used to avoid returning a nil
+// pointer as a non-nil error.
+func ToMultierror(err error) *multierror.Error {
+	errs := Split(err)
+	if len(errs) == 0 {
+		return nil
+	}
+	return &multierror.Error{Errors: errs}
+}
+
+// FromMultierror converts a go-multierror error into an aggregate created by
+// errors.Aggregate. Nil is returned if the error is nil or empty.
+func FromMultierror(err *multierror.Error) error {
+	return errors.Aggregate(err.WrappedErrors()...)
+}
+
+// ToMultierr converts the error into an error created using multierr.Combine,
+// see Split. Nil is returned for nil errors.
+func ToMultierr(err error) error {
+	return multierr.Combine(Split(err)...)
+}
+
+// FromMultierr converts an error created by multierr into an aggregate created
+// by errors.Aggregate.
+func FromMultierr(err error) error {
+	return errors.Aggregate(multierr.Errors(err)...)
+}
+
+// Split returns the errors aggregated by the error. Aggregates are flattened in
+// the same way as errors.Aggregate, errors that are not aggregates are
+// returned as the only element. Aggregates that have been enriched or wrapped
+// are not split, preserving their enrichments.
+func Split(err error) []error {
+	err = errors.Aggregate(err)
+	if aggregate, ok := err.(interface{ Errors() []error }); ok {
+		return aggregate.Errors()
+	}
+
+	if err == nil {
+		return nil
+	}
+	return []error{err}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errcompat_test
+
+import (
+	stderrors "errors"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/errcompat"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"go.uber.org/multierr"
+)
+
+// Detail is an enrichment used to test enrichments within aggregates
+type Detail string
+
+var (
+	err1 = errors.New("compat error 01")
+	err2 = errors.New("compat error 02")
+	err3 = errors.New("compat error 03")
+)
+
+var _ = Describe("Aggregate", func() {
+	DescribeTable("should flatten aggregates from other libraries",
+		func(aggregate error) {
+			err := errors.Aggregate(aggregate, err3)
+			Expect(err).To(MatchError("[compat error 01, compat error 02, compat error 03]"))
+			Expect(errcompat.Split(err)).To(Equal([]error{err1, err2, err3}))
+		},
+		Entry("errors.Join", stderrors.Join(err1, err2)),
+		Entry("go-multierror", multierror.Append(err1, err2)),
+		Entry("multierr", multierr.Combine(err1, err2)),
+		Entry("nested aggregates", errors.Aggregate(stderrors.Join(err1), multierror.Append(nil, multierr.Combine(err2)))),
+	)
+
+	DescribeTable("should find enrichments within aggregates from other libraries",
+		func(aggregate func(...error) error) {
+			err := errors.Enrich(aggregate(
+				err1,
+				errors.Enrich(err2, errors.Set[Detail]("detail"), errors.SetCategory(errors.CategoryNotFound)),
+				errors.Enrich(err3, errors.Set[errors.Retryable](true)),
+			), errors.Wrap("prefix"))
+
+			Expect(errors.Get[Detail](err, "")).To(Equal(Detail("detail")))
+			Expect(errors.Check[errors.Retryable](err)).To(BeTrue())
+			Expect(errors.Classify(err)).To(Equal(errors.CategoryNotFound))
+			Expect(errors.Is(err, err3)).To(BeTrue())
+			Expect(errors.Causes(err)).To(Equal([]error{err1, err2, err3}))
+		},
+		Entry("errors.Join", stderrors.Join),
+		Entry("go-multierror", func(errs ...error) error { return multierror.Append(nil, errs...) }),
+		Entry("multierr", multierr.Combine),
+	)
+
+	It("should ignore nil go-multierror errors", func() {
+		var merr *multierror.Error
+		Expect(errors.Aggregate(merr, err1)).To(Equal(err1))
+	})
+
+	It("should not flatten enriched aggregates", func() {
+		enriched := errors.Enrich(stderrors.Join(err1, err2), errors.Set[Detail]("detail"))
+		Expect(errcompat.Split(errors.Aggregate(enriched, err3))).To(Equal([]error{enriched, err3}))
+	})
+})
+
+var _ = Describe("Conversions", func() {
+	var aggregate error
+
+	BeforeEach(func() {
+		aggregate = errors.Aggregate(err1, err2)
+	})
+
+	It("should convert to and from errors.Join", func() {
+		joined := errcompat.ToJoin(aggregate)
+		Expect(joined.(interface{ Unwrap() []error }).Unwrap()).To(Equal([]error{err1, err2}))
+		Expect(errcompat.FromJoin(joined)).To(Equal(aggregate))
+	})
+
+	It("should convert to and from go-multierror", func() {
+		merr := errcompat.ToMultierror(aggregate)
+		Expect(merr.Errors).To(Equal([]error{err1, err2}))
+		Expect(errcompat.FromMultierror(merr)).To(Equal(aggregate))
+	})
+
+	It("should convert to and from multierr", func() {
+		merr := errcompat.ToMultierr(aggregate)
+		Expect(multierr.Errors(merr)).To(Equal([]error{err1, err2}))
+		Expect(errcompat.FromMultierr(merr)).To(Equal(aggregate))
+	})
+
+	It("should convert between libraries", func() {
+		merr := errcompat.ToMultierror(multierr.Combine(err1, err2))
+		Expect(stderrors.Is(errcompat.ToJoin(merr), err2)).To(BeTrue())
+		Expect(multierr.Errors(errcompat.ToMultierr(stderrors.Join(merr, err3)))).To(Equal([]error{err1, err2, err3}))
+	})
+
+	It("should return single errors as is", func() {
+		Expect(errcompat.ToJoin(err1).(interface{ Unwrap() []error }).Unwrap()).To(Equal([]error{err1}))
+		Expect(errcompat.ToMultierror(err1).Errors).To(Equal([]error{err1}))
+		Expect(errcompat.ToMultierr(err1)).To(Equal(err1))
+		Expect(errcompat.FromMultierr(err1)).To(Equal(err1))
+	})
+
+	It("should convert nil errors to nil", func() {
+		Expect(errcompat.ToJoin(nil)).To(BeNil())
+		Expect(errcompat.FromJoin(nil)).To(BeNil())
+		Expect(errcompat.ToMultierror(nil)).To(BeNil())
+		Expect(errcompat.FromMultierror(nil)).To(BeNil())
+		Expect(errcompat.ToMultierr(nil)).To(BeNil())
+		Expect(errcompat.FromMultierr(nil)).To(BeNil())
+		Expect(errcompat.Split(nil)).To(BeEmpty())
+	})
+})
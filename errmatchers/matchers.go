@@ -0,0 +1,208 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errmatchers provides Gomega matchers for errors created by this
+// library. Failure messages include the full error tree.
+package errmatchers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kubespress/errors"
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// HaveEnrichment succeeds if the error has been enriched with a value of type T
+// that matches expected. Expected can be a value, which is compared using
+// BeEquivalentTo so untyped constants can be used, or a matcher. The outermost
+// enrichment of the type is used.
+func HaveEnrichment[T any](expected any) types.GomegaMatcher {
+	return &errorMatcher{
+		description: fmt.Sprintf("to have enrichment %s", typeName[T]()),
+		expected:    expected,
+		match: func(err error) (bool, error) {
+			values := errors.All[T](err)
+			if len(values) == 0 {
+				return false, nil
+			}
+			return valueMatcher(expected).Match(values[0])
+		},
+	}
+}
+
+// BeRetryable succeeds if the error is retryable, see errors.IsRetryable
+func BeRetryable() types.GomegaMatcher {
+	return &errorMatcher{
+		description: "to be retryable",
+		match: func(err error) (bool, error) {
+			return errors.IsRetryable(err), nil
+		},
+	}
+}
+
+// HaveStackFrameIn succeeds if a stack attached to the error contains a frame
+// in the function. The function can be the fully qualified function name, or
+// a suffix of it such as "pkg.Function" or "Function".
+func HaveStackFrameIn(function string) types.GomegaMatcher {
+	return &errorMatcher{
+		description: "to have a stack frame in",
+		expected:    function,
+		match: func(err error) (bool, error) {
+			found := false
+			errors.Visit(err, func(err error) bool {
+				if stack, ok := err.(interface{ Callers() []uintptr }); ok {
+					for _, name := range frames(stack.Callers()) {
+						if name == function || strings.HasSuffix(name, "."+function) || strings.HasSuffix(name, "/"+function) {
+							found = true
+						}
+					}
+				}
+				return !found
+			})
+			return found, nil
+		},
+	}
+}
+
+// ContainError succeeds if any error within the error tree matches expected.
+// This is typically used to assert on the contents of aggregates. Expected can
+// be an error, which is compared using errors.Is, or a matcher which is
+// matched against each error in the tree.
+func ContainError(expected any) types.GomegaMatcher {
+	return &errorMatcher{
+		description: "to contain error",
+		expected:    expected,
+		match: func(err error) (found bool, merr error) {
+			switch expected := expected.(type) {
+			case error:
+				return errors.Is(err, expected), nil
+			case types.GomegaMatcher:
+				errors.Visit(err, func(err error) bool {
+					found, merr = expected.Match(err)
+					return !found && merr == nil
+				})
+				return found, merr
+			default:
+				return false, fmt.Errorf("ContainError expects an error or a matcher. Got:\n%s", format.Object(expected, 1))
+			}
+		},
+	}
+}
+
+// HaveWrapPrefix succeeds if the error, or any error it wraps, was wrapped with
+// the message. For example the error created by
+// errors.Enrich(err, errors.Wrap("failed")) has the wrap prefix "failed".
+func HaveWrapPrefix(msg string) types.GomegaMatcher {
+	return &errorMatcher{
+		description: "to have wrap prefix",
+		expected:    msg,
+		match: func(err error) (bool, error) {
+			found := false
+			errors.Visit(err, func(err error) bool {
+				prefix, ok := wrapPrefix(err)
+				found = ok && prefix == msg
+				return !found
+			})
+			return found, nil
+		},
+	}
+}
+
+// MatchErrorTree succeeds if the error tree is equivalent to the expected error
+// tree, see errors.Diff. The failure message lists the differences between the
+// trees.
+func MatchErrorTree(expected error) types.GomegaMatcher {
+	return &treeMatcher{expected: expected}
+}
+
+type treeMatcher struct {
+	expected error
+}
+
+func (m *treeMatcher) Match(actual any) (bool, error) {
+	err, ok := actual.(error)
+	if !ok && actual != nil {
+		return false, fmt.Errorf("Expected an error. Got:\n%s", format.Object(actual, 1))
+	}
+
+	return errors.Diff(err, m.expected) == "", nil
+}
+
+func (m *treeMatcher) FailureMessage(actual any) string {
+	err, _ := actual.(error)
+	diff := strings.ReplaceAll(errors.Diff(err, m.expected), "\n", "\n    ")
+	return fmt.Sprintf("Expected error\n%s\nto match error tree\n%s\ndifferences (actual != expected):\n    %s", Tree(err), Tree(m.expected), diff)
+}
+
+func (m *treeMatcher) NegatedFailureMessage(actual any) string {
+	err, _ := actual.(error)
+	return fmt.Sprintf("Expected error\n%s\nnot to match error tree\n%s", Tree(err), Tree(m.expected))
+}
+
+// errorMatcher is a matcher for errors, failure messages render the error tree
+type errorMatcher struct {
+	description string
+	expected    any
+	match       func(error) (bool, error)
+}
+
+func (m *errorMatcher) Match(actual any) (bool, error) {
+	if actual == nil {
+		return false, nil
+	}
+
+	err, ok := actual.(error)
+	if !ok {
+		return false, fmt.Errorf("Expected an error. Got:\n%s", format.Object(actual, 1))
+	}
+
+	return m.match(err)
+}
+
+func (m *errorMatcher) FailureMessage(actual any) string {
+	return m.message(actual, m.description)
+}
+
+func (m *errorMatcher) NegatedFailureMessage(actual any) string {
+	return m.message(actual, "not "+m.description)
+}
+
+func (m *errorMatcher) message(actual any, description string) string {
+	err, _ := actual.(error)
+	tree := Tree(err)
+
+	msg := fmt.Sprintf("Expected error\n%s\n%s", tree, description)
+	if m.expected != nil {
+		msg += "\n" + format.Object(m.expected, 1)
+	}
+	return msg
+}
+
+// valueMatcher returns the matcher if expected is a matcher, otherwise it
+// returns a matcher testing equivalence.
+func valueMatcher(expected any) types.GomegaMatcher {
+	if matcher, ok := expected.(types.GomegaMatcher); ok {
+		return matcher
+	}
+	return gomega.BeEquivalentTo(expected)
+}
+
+func typeName[T any]() string {
+	return fmt.Sprintf("%T", (*T)(nil))[1:]
+}
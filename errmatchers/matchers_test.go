@@ -0,0 +1,132 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errmatchers_test
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kubespress/errors"
+	. "github.com/kubespress/errors/errmatchers"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Reason is a test enrichment
+type Reason string
+
+func failWithStack() error {
+	return errors.Enrich(errors.New("test message 03"), errors.WithStack())
+}
+
+var _ = Describe("Matchers", func() {
+	var sentinel = errors.New("test message 01")
+
+	Describe("HaveEnrichment", func() {
+		It("should match the enrichment", func() {
+			err := errors.Enrich(sentinel, errors.Set[Reason]("inner"), errors.Set[Reason]("outer"), errors.Wrap("prefix"))
+			Expect(err).To(HaveEnrichment[Reason]("outer"))
+			Expect(err).To(HaveEnrichment[Reason](BeEquivalentTo("outer")))
+			Expect(err).ToNot(HaveEnrichment[Reason]("inner"))
+			Expect(err).To(HaveEnrichment[Reason](Reason("outer")))
+			Expect(sentinel).ToNot(HaveEnrichment[Reason]("outer"))
+			Expect(nil).ToNot(HaveEnrichment[Reason]("outer"))
+		})
+
+		It("should render the error tree on failure", func() {
+			err := errors.Enrich(sentinel, errors.Set[Reason]("outer"), errors.Wrap("prefix"))
+			Expect(HaveEnrichment[Reason]("other").FailureMessage(err)).To(Equal(fmt.Sprintf(`Expected error
+    %[1]T: prefix
+        enrichment errmatchers_test.Reason: outer
+            %[2]T: test message 01
+to have enrichment errmatchers_test.Reason
+    <string>: other`, err, sentinel)))
+		})
+
+		It("should error if actual is not an error", func() {
+			_, err := HaveEnrichment[Reason]("outer").Match("string")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("BeRetryable", func() {
+		It("should match retryable errors", func() {
+			Expect(fmt.Errorf("prefix: %w", context.DeadlineExceeded)).To(BeRetryable())
+			Expect(errors.Enrich(sentinel, errors.Set[errors.Retryable](true))).To(BeRetryable())
+			Expect(sentinel).ToNot(BeRetryable())
+			Expect(BeRetryable().NegatedFailureMessage(context.DeadlineExceeded)).To(HaveSuffix("not to be retryable"))
+		})
+	})
+
+	Describe("HaveStackFrameIn", func() {
+		It("should match stack frames", func() {
+			err := errors.Enrich(failWithStack(), errors.Wrap("prefix"))
+			Expect(err).To(HaveStackFrameIn("failWithStack"))
+			Expect(err).To(HaveStackFrameIn("errmatchers_test.failWithStack"))
+			Expect(err).To(HaveStackFrameIn("github.com/kubespress/errors/errmatchers_test.failWithStack"))
+			Expect(err).ToNot(HaveStackFrameIn("WithStack"))
+			Expect(sentinel).ToNot(HaveStackFrameIn("failWithStack"))
+		})
+
+		It("should render the stack on failure", func() {
+			Expect(HaveStackFrameIn("other").FailureMessage(failWithStack())).To(ContainSubstring("stack: github.com/kubespress/errors/errmatchers_test.failWithStack"))
+		})
+	})
+
+	Describe("ContainError", func() {
+		It("should match errors within aggregates", func() {
+			err := errors.Aggregate(errors.New("test message 02"), errors.Enrich(sentinel, errors.Wrap("prefix")))
+			Expect(err).To(ContainError(sentinel))
+			Expect(err).To(ContainError(MatchError("test message 02")))
+			Expect(err).ToNot(ContainError(context.Canceled))
+			Expect(err).ToNot(ContainError(MatchError("test message 03")))
+		})
+
+		It("should error for unsupported expected values", func() {
+			_, err := ContainError("test message 01").Match(sentinel)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("HaveWrapPrefix", func() {
+		It("should match wrap prefixes", func() {
+			err := errors.Enrich(sentinel, errors.Wrap("inner"), errors.Set[Reason]("reason"), errors.Wrapf("outer %d", 1))
+			Expect(err).To(HaveWrapPrefix("outer 1"))
+			Expect(err).To(HaveWrapPrefix("inner"))
+			Expect(fmt.Errorf("prefix: %w", sentinel)).To(HaveWrapPrefix("prefix"))
+			Expect(err).ToNot(HaveWrapPrefix("outer"))
+			Expect(sentinel).ToNot(HaveWrapPrefix("test message 01"))
+		})
+	})
+
+	Describe("MatchErrorTree", func() {
+		It("should match equivalent error trees", func() {
+			Expect(errors.Enrich(sentinel, errors.Set[Reason]("reason"), errors.Wrap("prefix"), errors.WithStack())).
+				To(MatchErrorTree(errors.Enrich(sentinel, errors.Set[Reason]("reason"), errors.Wrap("prefix"))))
+			Expect(nil).To(MatchErrorTree(nil))
+			Expect(sentinel).ToNot(MatchErrorTree(errors.Enrich(sentinel, errors.Wrap("prefix"))))
+		})
+
+		It("should list the differences on failure", func() {
+			actual := errors.Enrich(sentinel, errors.Set[Reason]("actual"), errors.Wrap("prefix"))
+			expected := errors.Enrich(sentinel, errors.Set[Reason]("expected"))
+			Expect(MatchErrorTree(expected).FailureMessage(actual)).To(HaveSuffix(`differences (actual != expected):
+    error.wrap[0]: "prefix" != <none>
+    error.enrichment(errmatchers_test.Reason): "actual" != "expected"`))
+		})
+	})
+})
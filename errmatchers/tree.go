@@ -0,0 +1,104 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errmatchers
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/internal/errtree"
+)
+
+// Tree renders the error tree, one error per line. Each line is indented and
+// wrapped errors are indented below the error wrapping them. It is used to
+// render errors in failure messages, it is exported so it can be used in custom
+// matchers.
+func Tree(err error) string {
+	if err == nil {
+		return "    <nil>"
+	}
+
+	var b strings.Builder
+	_ = errors.Walk(err, func(node errors.WalkNode) error {
+		b.WriteString(strings.Repeat("    ", node.Depth()+1))
+		b.WriteString(describe(node.Err))
+		b.WriteString("\n")
+		return nil
+	})
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// describe returns a single line describing the error, without the errors it
+// wraps.
+func describe(err error) string {
+	switch e := err.(type) {
+	case interface{ Enrichment() any }:
+		return fmt.Sprintf("enrichment %T: %v", e.Enrichment(), e.Enrichment())
+	case interface{ Callers() []uintptr }:
+		return "stack: " + topFrame(e.Callers())
+	}
+
+	if errtree.Multiple(err) {
+		return fmt.Sprintf("%T: %d errors", err, len(errtree.Children(err)))
+	}
+
+	if prefix, ok := wrapPrefix(err); ok {
+		return fmt.Sprintf("%T: %s", err, prefix)
+	}
+
+	return fmt.Sprintf("%T: %s", err, err.Error())
+}
+
+// wrapPrefix returns the message an error added to the error it wraps
+func wrapPrefix(err error) (string, bool) {
+	unwrapper, ok := err.(interface{ Unwrap() error })
+	if !ok {
+		return "", false
+	}
+
+	nested := unwrapper.Unwrap()
+	if nested == nil {
+		return "", false
+	}
+
+	return strings.CutSuffix(err.Error(), ": "+nested.Error())
+}
+
+// frames returns the function names of the stack
+func frames(callers []uintptr) []string {
+	var names []string
+	iter := runtime.CallersFrames(callers)
+	for {
+		frame, more := iter.Next()
+		if frame.Function != "" {
+			names = append(names, frame.Function)
+		}
+		if !more {
+			return names
+		}
+	}
+}
+
+func topFrame(callers []uintptr) string {
+	if names := frames(callers); len(names) > 0 {
+		return names[0]
+	}
+	return "unknown"
+}
@@ -17,19 +17,59 @@ limitations under the License.
 package errors
 
 import (
-	"errors"
 	"fmt"
+	"log/slog"
+	"reflect"
+
+	"github.com/kubespress/errors/internal/errtree"
 )
 
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
 // As finds the first error in err's tree that matches target, and if one is found, sets
-// target to that error value and returns true. Otherwise, it returns false.
+// target to that error value and returns true. Otherwise, it returns false. It
+// behaves the same as the standard library errors.As, however the tree is
+// searched in the same way as Walk so it is protected against cycles.
 func As(err error, target interface{}) bool {
-	return errors.As(err, target)
+	if err == nil {
+		return false
+	}
+
+	// Validate the target in the same way as the standard library
+	if target == nil {
+		panic("errors: target cannot be nil")
+	}
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		panic("errors: target must be a non-nil pointer")
+	}
+	targetType := value.Type().Elem()
+	if targetType.Kind() != reflect.Interface && !targetType.Implements(errorType) {
+		panic("errors: *target must be interface or implement error")
+	}
+
+	return searchChain(err, func(err error) bool {
+		if reflect.TypeOf(err).AssignableTo(targetType) {
+			value.Elem().Set(reflect.ValueOf(err))
+			return true
+		}
+
+		as, ok := err.(interface{ As(any) bool })
+		return ok && as.As(target)
+	})
 }
 
-// Is reports whether any error in err's tree matches target.
+// Is reports whether any error in err's tree matches target. It behaves the
+// same as the standard library errors.Is, however the tree is searched in the
+// same way as Walk so it is protected against cycles.
 func Is(err error, target error) bool {
-	return errors.Is(err, target)
+	if err == nil || target == nil {
+		return err == target
+	}
+
+	return searchChain(err, func(err error) bool {
+		return matches(err, target)
+	})
 }
 
 type errorString struct {
@@ -43,11 +83,6 @@ func New(msg string) error {
 	return errorString{msg: msg}
 }
 
-// Errorf creates a new error using string formatting.
-func Errorf(msg string, args ...any) error {
-	return fmt.Errorf(msg, args...)
-}
-
 // Enricher is an error enrichment, it adds additional context to the provided
 // error.
 type Enricher func(error) error
@@ -77,11 +112,15 @@ func Enrich(err error, enrichments ...Enricher) error {
 
 type enrichedError[T any] struct {
 	enrichment T
+	sensitive  bool
 	nested     error
 }
 
-func (err enrichedError[T]) Error() string { return err.nested.Error() }
-func (err enrichedError[T]) Unwrap() error { return err.nested }
+func (err enrichedError[T]) Error() string        { return err.nested.Error() }
+func (err enrichedError[T]) Unwrap() error        { return err.nested }
+func (err enrichedError[T]) Enrichment() any      { return err.enrichment }
+func (err enrichedError[T]) Sensitive() bool      { return err.sensitive }
+func (err enrichedError[T]) LogValue() slog.Value { return chainLogValue(err) }
 func (err enrichedError[T]) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
@@ -108,25 +147,19 @@ func Set[T any](value T) Enricher {
 }
 
 // Check returns true if the error contains the specified context and it is true.
+// If the error does not contain the context, the classifiers registered for
+// the type are used, see RegisterClassifier.
 func Check[T ~bool](err error) bool {
-	// Check if error has enrichment
-	var unwrapped enrichedError[T]
-	if errors.As(err, &unwrapped) {
-		// Cast enrichment to bool and return
-		return bool(unwrapped.enrichment)
-	}
-
-	// Does not have enrichment
-	return false
+	value, _ := lookup[T](err)
+	return bool(value)
 }
 
 // Get returns the enriched context if it exists in the error, otherwise it
-// returns the provided default value.
+// returns the value from the classifiers registered for the type, see
+// RegisterClassifier. If neither exists it returns the provided default value.
 func Get[T any](err error, def T) T {
-	// Check if error has enrichment
-	var unwrapped enrichedError[T]
-	if errors.As(err, &unwrapped) {
-		return unwrapped.enrichment
+	if value, ok := lookup[T](err); ok {
+		return value
 	}
 
 	// Does not have enrichment
@@ -135,35 +168,40 @@ func Get[T any](err error, def T) T {
 
 // All returns the enriched context if it exists in the error. As opposed to Get
 // this function returns all the enriched values instead of stopping at the
-// first one.
+// first one. Values are returned in the order they are found walking the error
+// tree, so the outermost value is first.
 func All[T any](err error) (results []T) {
-	for err != nil {
-		// Check if error has enrichment
-		var unwrapped enrichedError[T]
-		if !errors.As(err, &unwrapped) {
-			return results
+	_ = Walk(err, func(node WalkNode) error {
+		if enriched, ok := node.Err.(enrichedError[T]); ok {
+			results = append(results, enriched.enrichment)
 		}
-
-		// Append the results
-		results = append(results, unwrapped.enrichment)
-		err = unwrapped.Unwrap()
-	}
+		return nil
+	})
 
 	return results
 }
 
 type wrappedError struct {
 	msg    string
+	tmpl   *template
+	lazy   bool
 	nested error
 }
 
-func (err wrappedError) Error() string { return err.msg + ": " + err.nested.Error() }
+// template is the format template and arguments used to create an error. It is
+// stored behind a pointer so errors holding it remain comparable.
+type template struct {
+	format string
+	args   []any
+}
+
+func (err wrappedError) Error() string { return err.message() + ": " + err.nested.Error() }
 func (err wrappedError) Unwrap() error { return err.nested }
 func (err wrappedError) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		if s.Flag('+') {
-			fmt.Fprintf(s, "%s: %+v", err.msg, err.Unwrap())
+			fmt.Fprintf(s, "%s: %+v", err.message(), err.Unwrap())
 			return
 		}
 		fallthrough
@@ -174,6 +212,25 @@ func (err wrappedError) Format(s fmt.State, verb rune) {
 	}
 }
 
+func (err wrappedError) Template() (string, []any) {
+	if err.tmpl == nil {
+		return err.msg, nil
+	}
+	return err.tmpl.format, err.tmpl.args
+}
+
+func (err wrappedError) LogValue() slog.Value {
+	format, args := err.Template()
+	return templateLogValue(err.Error(), format, args)
+}
+
+func (err wrappedError) message() string {
+	if err.lazy {
+		return fmt.Sprintf(err.tmpl.format, err.tmpl.args...)
+	}
+	return err.msg
+}
+
 // Wrap returns an enricher that prefixes an error message with a provided
 // string for additional context
 func Wrap(msg string) Enricher {
@@ -186,32 +243,52 @@ func Wrap(msg string) Enricher {
 }
 
 // Wrapf returns an enricher that prefixes an error message with a provided
-// string for additional context
+// string for additional context. The format template and arguments are
+// preserved, see Template.
 func Wrapf(msg string, args ...interface{}) Enricher {
-	return Wrap(fmt.Sprintf(msg, args...))
+	return func(err error) error {
+		return wrappedError{
+			msg:    fmt.Sprintf(msg, args...),
+			tmpl:   &template{format: msg, args: args},
+			nested: err,
+		}
+	}
+}
+
+// WrapfLazy is the same as Wrapf, however formatting is deferred until the
+// error message is requested. This avoids the cost of formatting errors that
+// are handled without ever being printed. As the arguments are formatted each
+// time the message is requested they should not be modified once passed in.
+func WrapfLazy(msg string, args ...interface{}) Enricher {
+	return func(err error) error {
+		return wrappedError{
+			tmpl:   &template{format: msg, args: args},
+			lazy:   true,
+			nested: err,
+		}
+	}
 }
 
 // Visit will unwrap the error recursively, calling the provided function for
-// each error.
+// each error. Returning false from the function stops visiting errors. Errors
+// are visited using Walk, so it is protected against cycles.
 func Visit(err error, fn func(error) bool) {
-	visit(err, fn)
+	_ = Walk(err, func(node WalkNode) error {
+		if !fn(node.Err) {
+			return SkipAll
+		}
+		return nil
+	})
 }
 
-func visit(err error, fn func(error) bool) bool {
-	if !fn(err) {
-		return false
+// unwrap returns the error wrapped by err, or nil if it does not wrap a single
+// error.
+func unwrap(err error) error {
+	if _, ok := errtree.Aggregated(err); ok {
+		return nil
 	}
-
-	switch unwrapped := err.(type) {
-	case interface{ Unwrap() error }:
-		return visit(unwrapped.Unwrap(), fn)
-	case interface{ Unwrap() []error }:
-		for _, err := range unwrapped.Unwrap() {
-			if !visit(err, fn) {
-				return false
-			}
-		}
+	if unwrapped, ok := err.(interface{ Unwrap() error }); ok {
+		return unwrapped.Unwrap()
 	}
-
-	return true
+	return nil
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package errors_test
 
 import (
+	stderrors "errors"
 	"fmt"
 
 	"github.com/kubespress/errors"
@@ -101,6 +102,29 @@ var _ = Describe("Errorf", func() {
 	})
 })
 
+// equatableError is an error that cannot be compared using ==, it defines
+// its own equality using an Is method
+type equatableError []string
+
+func (err equatableError) Error() string { return fmt.Sprint([]string(err)) }
+func (err equatableError) Is(target error) bool {
+	other, ok := target.(equatableError)
+	return ok && fmt.Sprint(err) == fmt.Sprint(other)
+}
+
+var _ = Describe("Is", func() {
+	DescribeTable("should match the standard library",
+		func(err, target error, expected bool) {
+			Expect(errors.Is(err, target)).To(Equal(expected))
+			Expect(stderrors.Is(err, target)).To(Equal(expected))
+		},
+		Entry("uncomparable errors with an Is method", equatableError{"a"}, equatableError{"a"}, true),
+		Entry("different uncomparable errors", equatableError{"a"}, equatableError{"b"}, false),
+		Entry("wrapped uncomparable errors", errors.Enrich(equatableError{"a"}, errors.Wrap("prefix")), equatableError{"a"}, true),
+		Entry("uncomparable target", errors.New("test message 41"), equatableError{"a"}, false),
+	)
+})
+
 var _ = Describe("Enrich", func() {
 	var err error
 
@@ -203,5 +227,19 @@ var _ = Describe("Enrich", func() {
 				Expect(fmt.Sprintf("%q", err)).To(Equal(`"message prefix: test message 03"`))
 			})
 		})
+
+		Context("when a wrapped error is used as a sentinel", func() {
+			DescribeTable("should match itself",
+				func(enricher errors.Enricher) {
+					sentinel := errors.Enrich(err, enricher)
+					Expect(errors.Is(sentinel, sentinel)).To(BeTrue())
+					Expect(stderrors.Is(sentinel, sentinel)).To(BeTrue())
+					Expect(sentinel == sentinel).To(BeTrue())
+				},
+				Entry("Wrap", errors.Wrap("message prefix")),
+				Entry("Wrapf", errors.Wrapf("message prefix %d", 1)),
+				Entry("WrapfLazy", errors.WrapfLazy("message prefix %d", 1)),
+			)
+		})
 	})
 })
@@ -0,0 +1,135 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errtest provides helpers for asserting on errors in tests using the
+// testing package.
+package errtest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+
+	"github.com/kubespress/errors"
+)
+
+// TB is the subset of testing.TB used by the assertions, this allows them to be
+// used with other test frameworks such as Ginkgo.
+type TB interface {
+	Helper()
+	Errorf(format string, args ...any)
+	Fatalf(format string, args ...any)
+}
+
+var update = flag.Bool("errtest.update", false, "update golden files compared using errtest.AssertGolden")
+
+// AssertIs reports a test failure if the error does not match the target using
+// errors.Is. It returns true if the assertion succeeded.
+func AssertIs(t TB, err, target error) bool {
+	t.Helper()
+	if errors.Is(err, target) {
+		return true
+	}
+
+	t.Errorf("expected error to match %v, got:\n%+v", target, err)
+	return false
+}
+
+// AssertEnriched reports a test failure if the error has not been enriched
+// with the expected value of type T. The outermost enrichment of the type is
+// compared using reflect.DeepEqual. It returns true if the assertion
+// succeeded.
+func AssertEnriched[T any](t TB, err error, expected T) bool {
+	t.Helper()
+	values := errors.All[T](err)
+	if len(values) == 0 {
+		t.Errorf("expected error to be enriched with %T, got:\n%+v", expected, err)
+		return false
+	}
+
+	if !reflect.DeepEqual(values[0], expected) {
+		t.Errorf("expected error to be enriched with %T %#v, got %#v", expected, expected, values[0])
+		return false
+	}
+
+	return true
+}
+
+// AssertAggregateLen reports a test failure if the error does not aggregate
+// the expected number of errors. Nested aggregates are flattened, a nil error
+// has a length of zero and an error that is not an aggregate has a length of
+// one. It returns true if the assertion succeeded.
+func AssertAggregateLen(t TB, err error, expected int) bool {
+	t.Helper()
+	if actual := len(errors.LeafPaths(err)); actual != expected {
+		t.Errorf("expected error to aggregate %d errors, got %d:\n%+v", expected, actual, err)
+		return false
+	}
+
+	return true
+}
+
+// AssertGolden reports a test failure if the %+v rendering of the error does
+// not match the contents of the golden file. The rendering is normalised so it
+// is deterministic, file paths, line numbers and addresses are removed. If the
+// test is run with the -errtest.update flag the golden file is written
+// instead. It returns true if the assertion succeeded.
+func AssertGolden(t TB, err error, path string) bool {
+	t.Helper()
+	actual := Normalize(fmt.Sprintf("%+v", err))
+
+	// Update the golden file if requested
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create golden file directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(actual), 0o644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return true
+	}
+
+	// Compare to the golden file
+	expected, readErr := os.ReadFile(path)
+	if readErr != nil {
+		t.Fatalf("failed to read golden file, run with -errtest.update to create it: %v", readErr)
+		return false
+	}
+
+	if !bytes.Equal(expected, []byte(actual)) {
+		t.Errorf("error does not match golden file %s, run with -errtest.update to update it\nexpected:\n%s\nactual:\n%s", path, expected, actual)
+		return false
+	}
+
+	return true
+}
+
+var (
+	fileLineRegexp = regexp.MustCompile(`(?m)^\t.*:\d+$`)
+	addressRegexp  = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+)
+
+// Normalize removes the non-deterministic parts of an error rendering. The
+// file and line of each stack frame output by errors.WithStack is replaced with
+// a placeholder, as are addresses.
+func Normalize(rendered string) string {
+	rendered = fileLineRegexp.ReplaceAllString(rendered, "\t<file>:<line>")
+	return addressRegexp.ReplaceAllString(rendered, "<address>")
+}
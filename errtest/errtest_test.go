@@ -0,0 +1,160 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errtest_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/errtest"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// Reason is a test enrichment
+type Reason string
+
+// fakeTB records failures reported by the assertions
+type fakeTB struct {
+	errors []string
+	fatal  bool
+}
+
+func (t *fakeTB) Helper() {}
+
+func (t *fakeTB) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeTB) Fatalf(format string, args ...any) {
+	t.Errorf(format, args...)
+	t.fatal = true
+}
+
+func failWithStack() error {
+	return errors.Enrich(errors.New("test message 03"), errors.WithStack(), errors.Wrap("prefix"))
+}
+
+// stableStack returns an error with a stack that does not depend on the test
+// framework, by capturing it in a new goroutine
+func stableStack() error {
+	result := make(chan error)
+	go sendStack(result)
+	return <-result
+}
+
+func sendStack(result chan<- error) {
+	result <- failWithStack()
+}
+
+var _ = Describe("Assertions", func() {
+	var sentinel = errors.New("test message 01")
+	var t *fakeTB
+
+	BeforeEach(func() {
+		t = &fakeTB{}
+	})
+
+	Describe("AssertIs", func() {
+		It("should pass if the error matches", func() {
+			Expect(errtest.AssertIs(t, errors.Enrich(sentinel, errors.Wrap("prefix")), sentinel)).To(BeTrue())
+			Expect(t.errors).To(BeEmpty())
+		})
+
+		It("should fail if the error does not match", func() {
+			Expect(errtest.AssertIs(t, errors.New("test message 02"), sentinel)).To(BeFalse())
+			Expect(t.errors).To(ConsistOf("expected error to match test message 01, got:\ntest message 02"))
+		})
+	})
+
+	Describe("AssertEnriched", func() {
+		It("should pass if the error is enriched", func() {
+			Expect(errtest.AssertEnriched[Reason](t, errors.Enrich(sentinel, errors.Set[Reason]("test")), "test")).To(BeTrue())
+			Expect(t.errors).To(BeEmpty())
+		})
+
+		It("should fail if the error is not enriched", func() {
+			Expect(errtest.AssertEnriched[Reason](t, sentinel, "test")).To(BeFalse())
+			Expect(t.errors).To(ConsistOf("expected error to be enriched with errtest_test.Reason, got:\ntest message 01"))
+		})
+
+		It("should fail if the value differs", func() {
+			Expect(errtest.AssertEnriched[Reason](t, errors.Enrich(sentinel, errors.Set[Reason]("other")), "test")).To(BeFalse())
+			Expect(t.errors).To(ConsistOf(`expected error to be enriched with errtest_test.Reason "test", got "other"`))
+		})
+	})
+
+	Describe("AssertAggregateLen", func() {
+		It("should count aggregated errors", func() {
+			Expect(errtest.AssertAggregateLen(t, nil, 0)).To(BeTrue())
+			Expect(errtest.AssertAggregateLen(t, sentinel, 1)).To(BeTrue())
+			Expect(errtest.AssertAggregateLen(t, errors.Enrich(errors.Aggregate(
+				sentinel,
+				errors.Aggregate(errors.New("test message 02"), errors.New("test message 03")),
+			), errors.Wrap("prefix")), 3)).To(BeTrue())
+			Expect(t.errors).To(BeEmpty())
+		})
+
+		It("should count errors aggregated by go-multierror", func() {
+			Expect(errtest.AssertAggregateLen(t, multierror.Append(sentinel, errors.New("test message 02")), 2)).To(BeTrue())
+			Expect(t.errors).To(BeEmpty())
+		})
+
+		It("should fail if the length differs", func() {
+			Expect(errtest.AssertAggregateLen(t, sentinel, 2)).To(BeFalse())
+			Expect(t.errors).To(ConsistOf("expected error to aggregate 2 errors, got 1:\ntest message 01"))
+		})
+	})
+
+	Describe("AssertGolden", func() {
+		It("should pass if the rendering matches", func() {
+			Expect(errtest.AssertGolden(t, stableStack(), filepath.Join("testdata", "stack.golden"))).To(BeTrue())
+			Expect(t.errors).To(BeEmpty())
+		})
+
+		It("should fail if the rendering differs", func() {
+			Expect(errtest.AssertGolden(t, sentinel, filepath.Join("testdata", "stack.golden"))).To(BeFalse())
+			Expect(t.errors).To(HaveLen(1))
+			Expect(t.fatal).To(BeFalse())
+		})
+
+		It("should fail if the golden file does not exist", func() {
+			Expect(errtest.AssertGolden(t, sentinel, filepath.Join(GinkgoT().TempDir(), "missing.golden"))).To(BeFalse())
+			Expect(t.fatal).To(BeTrue())
+		})
+	})
+
+	Describe("Normalize", func() {
+		It("should remove file paths, line numbers and addresses", func() {
+			rendered := fmt.Sprintf("%+v", failWithStack())
+			Expect(rendered).To(MatchRegexp(`\t/.*\.go:\d+`))
+			Expect(errtest.Normalize(rendered)).ToNot(MatchRegexp(`\.go:\d+`))
+			Expect(errtest.Normalize("pointer 0xc000012345")).To(Equal("pointer <address>"))
+		})
+	})
+})
+
+var _ = Describe("Golden files", func() {
+	It("should be stable", func() {
+		golden, err := os.ReadFile(filepath.Join("testdata", "stack.golden"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(golden)).To(HavePrefix("prefix: test message 03\ngithub.com/kubespress/errors/errtest_test.failWithStack\n\t<file>:<line>\n"))
+	})
+})
@@ -0,0 +1,224 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errwire
+
+import (
+	"github.com/kubespress/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ErrInvalidEncoding is returned when decoding data that is not a valid Error
+// message
+var ErrInvalidEncoding = errors.New("invalid error encoding")
+
+// Decode reconstructs an error tree encoded using Encode. Sentinels registered
+// using errors.Register are replaced with the sentinel, other errors that do
+// not wrap other errors are decoded as a *RemoteError. Enrichments that are not
+// registered by the receiver are dropped. Empty data is decoded as a nil
+// error. The second result is non-nil if the data could not be decoded, it
+// matches ErrInvalidEncoding if the data is not a valid encoding.
+func Decode(data []byte) (error, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	msg, err := parseError(data, 0)
+	if err != nil {
+		return nil, errors.Enrich(err, errors.Wrap("failed to decode error"))
+	}
+
+	decoded, err := msg.build()
+	if err != nil {
+		return nil, errors.Enrich(err, errors.Wrap("failed to decode error"))
+	}
+
+	return decoded, nil
+}
+
+// message is a parsed Error message
+type message struct {
+	message    string
+	kind       uint64
+	typ        string
+	sentinelID string
+	enrichment struct {
+		name  string
+		value []byte
+	}
+	stack    []Frame
+	cause    *message
+	children []*message
+}
+
+func parseError(data []byte, depth int) (*message, error) {
+	// Limit the depth of decoded error trees, protecting the receiver from
+	// malicious input
+	if depth > errors.MaxDepth {
+		return nil, errors.Enrich(ErrInvalidEncoding, errors.Wrapf("maximum depth of %d exceeded", errors.MaxDepth))
+	}
+
+	msg := &message{}
+	err := parseFields(data, func(field protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+		switch {
+		case field == errorMessageField && typ == protowire.BytesType:
+			msg.message = string(value)
+		case field == errorKindField && typ == protowire.VarintType:
+			msg.kind = varint
+		case field == errorTypeField && typ == protowire.BytesType:
+			msg.typ = string(value)
+		case field == errorSentinelIDField && typ == protowire.BytesType:
+			msg.sentinelID = string(value)
+		case field == errorEnrichmentField && typ == protowire.BytesType:
+			return parseFields(value, func(field protowire.Number, typ protowire.Type, value []byte, _ uint64) error {
+				switch {
+				case field == enrichmentNameField && typ == protowire.BytesType:
+					msg.enrichment.name = string(value)
+				case field == enrichmentValueField && typ == protowire.BytesType:
+					msg.enrichment.value = value
+				}
+				return nil
+			})
+		case field == errorStackField && typ == protowire.BytesType:
+			msg.stack = append(msg.stack, Frame{})
+			return parseFields(value, func(field protowire.Number, typ protowire.Type, value []byte, varint uint64) error {
+				frame := &msg.stack[len(msg.stack)-1]
+				switch {
+				case field == frameFunctionField && typ == protowire.BytesType:
+					frame.Function = string(value)
+				case field == frameFileField && typ == protowire.BytesType:
+					frame.File = string(value)
+				case field == frameLineField && typ == protowire.VarintType:
+					frame.Line = int(varint)
+				}
+				return nil
+			})
+		case field == errorCauseField && typ == protowire.BytesType:
+			cause, err := parseError(value, depth+1)
+			msg.cause = cause
+			return err
+		case field == errorChildrenField && typ == protowire.BytesType:
+			child, err := parseError(value, depth+1)
+			msg.children = append(msg.children, child)
+			return err
+		}
+		return nil
+	})
+
+	return msg, err
+}
+
+// parseFields calls the function for each field in the message, unknown fields
+// are passed to the function and should be ignored.
+func parseFields(data []byte, fn func(field protowire.Number, typ protowire.Type, value []byte, varint uint64) error) error {
+	for len(data) > 0 {
+		field, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return errors.Enrich(ErrInvalidEncoding, errors.Wrap(protowire.ParseError(n).Error()))
+		}
+		data = data[n:]
+
+		var value []byte
+		var varint uint64
+		switch typ {
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(data)
+		case protowire.VarintType:
+			varint, n = protowire.ConsumeVarint(data)
+		default:
+			n = protowire.ConsumeFieldValue(field, typ, data)
+		}
+		if n < 0 {
+			return errors.Enrich(ErrInvalidEncoding, errors.Wrap(protowire.ParseError(n).Error()))
+		}
+		data = data[n:]
+
+		if err := fn(field, typ, value, varint); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// build reconstructs the error from the parsed message
+func (msg *message) build() (error, error) {
+	// Rehydrate registered sentinels
+	if msg.sentinelID != "" {
+		if sentinel, ok := errors.Sentinel(msg.sentinelID); ok {
+			return sentinel, nil
+		}
+	}
+
+	// Build the wrapped errors
+	var cause error
+	if msg.cause != nil {
+		var err error
+		if cause, err = msg.cause.build(); err != nil {
+			return nil, err
+		}
+	}
+
+	switch msg.kind {
+	case kindLeaf:
+		return &RemoteError{Message: msg.message, Type: msg.typ, SentinelID: msg.sentinelID}, nil
+
+	case kindWrap:
+		if cause == nil {
+			return nil, errors.Enrich(ErrInvalidEncoding, errors.Wrap("wrapped error has no cause"))
+		}
+		return &wrapError{msg: msg.message, typ: msg.typ, cause: cause}, nil
+
+	case kindEnrichment:
+		if cause == nil {
+			return nil, errors.Enrich(ErrInvalidEncoding, errors.Wrap("enrichment has no cause"))
+		}
+
+		// Enrichments not registered by the receiver are dropped
+		registry.RLock()
+		codec, ok := registry.names[msg.enrichment.name]
+		registry.RUnlock()
+		if !ok {
+			return cause, nil
+		}
+
+		enricher, err := codec.decode(msg.enrichment.value)
+		if err != nil {
+			return nil, err
+		}
+		return errors.Enrich(cause, enricher), nil
+
+	case kindStack:
+		if cause == nil {
+			return nil, errors.Enrich(ErrInvalidEncoding, errors.Wrap("stack has no cause"))
+		}
+		return &stackError{frames: msg.stack, cause: cause}, nil
+
+	case kindAggregate:
+		errs := make([]error, 0, len(msg.children))
+		for _, child := range msg.children {
+			err, buildErr := child.build()
+			if buildErr != nil {
+				return nil, buildErr
+			}
+			errs = append(errs, err)
+		}
+		return &aggregateError{msg: msg.message, typ: msg.typ, errs: errs}, nil
+
+	default:
+		return nil, errors.Enrich(ErrInvalidEncoding, errors.Wrapf("unknown kind %d", msg.kind))
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errwire
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/internal/errtree"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Encode encodes the error tree as an Error message, see errors.proto. A nil
+// error is encoded as nil.
+func Encode(err error) []byte {
+	if err == nil {
+		return nil
+	}
+
+	// Each error is encoded once the errors it wraps have been encoded, so they
+	// can be embedded within it
+	var result []byte
+	var pending []pendingError
+	_ = errors.Walker{
+		Pre: func(node errors.WalkNode) error {
+			b, field, leaf := encodeError(node.Err)
+			pending = append(pending, pendingError{b: b, field: field})
+			if leaf {
+				return errors.SkipSubtree
+			}
+			return nil
+		},
+		Post: func(node errors.WalkNode) error {
+			current := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+
+			// Errors that are not encoded are replaced by the error they wrap,
+			// if it was not visited they are encoded as a leaf
+			encoded := current.b
+			if current.field == 0 && encoded == nil {
+				encoded = appendLeaf(nil, node.Err)
+			}
+
+			if len(pending) == 0 {
+				result = encoded
+				return nil
+			}
+
+			parent := &pending[len(pending)-1]
+			if parent.field == 0 {
+				parent.b = encoded
+				return nil
+			}
+			parent.b = protowire.AppendTag(parent.b, parent.field, protowire.BytesType)
+			parent.b = protowire.AppendBytes(parent.b, encoded)
+			return nil
+		},
+	}.Walk(err)
+
+	return result
+}
+
+// pendingError is an error whose encoding is incomplete until the errors it
+// wraps have been encoded
+type pendingError struct {
+	b []byte
+	// field is the field the wrapped errors are embedded in, it is zero if
+	// the error is replaced by the error it wraps
+	field protowire.Number
+}
+
+// encodeError encodes the error without the errors it wraps. It returns the
+// field the wrapped errors should be embedded in, zero if the error should be
+// replaced by the error it wraps, and whether the wrapped errors are needed.
+func encodeError(err error) (b []byte, field protowire.Number, leaf bool) {
+	// Registered sentinels are encoded by ID, the errors they wrap are not
+	// needed as the receiver has the sentinel
+	if id, ok := errors.SentinelID(err); ok {
+		b = appendString(b, errorMessageField, err.Error())
+		b = appendString(b, errorTypeField, fmt.Sprintf("%T", err))
+		return appendString(b, errorSentinelIDField, id), 0, true
+	}
+
+	switch e := err.(type) {
+	// Errors previously decoded are encoded as they were received
+	case *RemoteError:
+		b = appendString(b, errorMessageField, e.Message)
+		b = appendString(b, errorTypeField, e.Type)
+		return appendString(b, errorSentinelIDField, e.SentinelID), 0, true
+
+	// Enrichments are only encoded if they are registered
+	case interface{ Enrichment() any }:
+		name, value, ok := encodeEnrichment(err, e.Enrichment())
+		if !ok {
+			return nil, 0, false
+		}
+
+		b = appendVarint(b, errorKindField, kindEnrichment)
+		b = protowire.AppendTag(b, errorEnrichmentField, protowire.BytesType)
+		return protowire.AppendBytes(b, appendEnrichment(nil, name, value)), errorCauseField, false
+
+	// Stacks are encoded as frames
+	case interface{ Callers() []uintptr }:
+		return appendStack(b, framesForCallers(e.Callers())), errorCauseField, false
+	case interface{ Frames() []Frame }:
+		return appendStack(b, e.Frames()), errorCauseField, false
+	}
+
+	switch {
+	// Errors wrapping multiple errors
+	case errtree.Multiple(err):
+		b = appendString(b, errorMessageField, err.Error())
+		b = appendVarint(b, errorKindField, kindAggregate)
+		return appendString(b, errorTypeField, typeName(err)), errorChildrenField, false
+
+	// Errors wrapping a single error
+	case len(errtree.Children(err)) > 0:
+		b = appendString(b, errorMessageField, err.Error())
+		b = appendVarint(b, errorKindField, kindWrap)
+		return appendString(b, errorTypeField, typeName(err)), errorCauseField, false
+	}
+
+	// Any other error is a leaf
+	return appendLeaf(b, err), 0, true
+}
+
+func appendLeaf(b []byte, err error) []byte {
+	b = appendString(b, errorMessageField, err.Error())
+	return appendString(b, errorTypeField, typeName(err))
+}
+
+func appendStack(b []byte, frames []Frame) []byte {
+	b = appendVarint(b, errorKindField, kindStack)
+	for _, frame := range frames {
+		var f []byte
+		f = appendString(f, frameFunctionField, frame.Function)
+		f = appendString(f, frameFileField, frame.File)
+		f = appendVarint(f, frameLineField, uint64(frame.Line))
+
+		b = protowire.AppendTag(b, errorStackField, protowire.BytesType)
+		b = protowire.AppendBytes(b, f)
+	}
+	return b
+}
+
+func appendEnrichment(b []byte, name string, value []byte) []byte {
+	b = appendString(b, enrichmentNameField, name)
+	b = protowire.AppendTag(b, enrichmentValueField, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
+
+// encodeEnrichment returns the registered name and JSON value of the
+// enrichment. Sensitive enrichments are never encoded.
+func encodeEnrichment(err error, enrichment any) (string, []byte, bool) {
+	if sensitive, ok := err.(interface{ Sensitive() bool }); ok && sensitive.Sensitive() {
+		return "", nil, false
+	}
+
+	registry.RLock()
+	codec, ok := registry.enrichments[reflect.TypeOf(enrichment)]
+	registry.RUnlock()
+	if !ok {
+		return "", nil, false
+	}
+
+	value, jsonErr := json.Marshal(enrichment)
+	if jsonErr != nil {
+		return "", nil, false
+	}
+
+	return codec.name, value, true
+}
+
+func framesForCallers(callers []uintptr) []Frame {
+	var frames []Frame
+	iter := runtime.CallersFrames(callers)
+	for {
+		frame, more := iter.Next()
+		frames = append(frames, Frame{Function: frame.Function, File: frame.File, Line: frame.Line})
+		if !more {
+			return frames
+		}
+	}
+}
+
+func typeName(err error) string {
+	switch e := err.(type) {
+	case *wrapError:
+		return e.typ
+	case *aggregateError:
+		return e.typ
+	}
+	return fmt.Sprintf("%T", err)
+}
+
+func appendString(b []byte, field protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, field, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendVarint(b []byte, field protowire.Number, value uint64) []byte {
+	if value == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, field, protowire.VarintType)
+	return protowire.AppendVarint(b, value)
+}
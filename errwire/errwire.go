@@ -0,0 +1,194 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errwire encodes errors so they can be sent across process
+// boundaries. The encoding is the protobuf Error message defined in
+// errors.proto. Messages, wrapped errors, registered enrichments, stack frames
+// and aggregated errors are preserved. Sentinel errors registered using
+// errors.Register are rehydrated when decoded, so errors.Is continues to work
+// on the receiver.
+package errwire
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/kubespress/errors"
+)
+
+// Field numbers of the messages defined in errors.proto
+const (
+	errorMessageField    = 1
+	errorKindField       = 2
+	errorTypeField       = 3
+	errorSentinelIDField = 4
+	errorEnrichmentField = 5
+	errorStackField      = 6
+	errorCauseField      = 7
+	errorChildrenField   = 8
+
+	enrichmentNameField  = 1
+	enrichmentValueField = 2
+
+	frameFunctionField = 1
+	frameFileField     = 2
+	frameLineField     = 3
+)
+
+// Values of the Error.Kind enum defined in errors.proto
+const (
+	kindLeaf = iota
+	kindWrap
+	kindEnrichment
+	kindStack
+	kindAggregate
+)
+
+type enrichmentCodec struct {
+	name   string
+	decode func([]byte) (errors.Enricher, error)
+}
+
+var registry = struct {
+	sync.RWMutex
+	enrichments map[reflect.Type]enrichmentCodec
+	names       map[string]enrichmentCodec
+}{
+	enrichments: map[reflect.Type]enrichmentCodec{},
+	names:       map[string]enrichmentCodec{},
+}
+
+func init() {
+	RegisterEnrichment[errors.Category]("errors.Category")
+	RegisterEnrichment[errors.Retryable]("errors.Retryable")
+	RegisterEnrichment[errors.Fault]("errors.Fault")
+}
+
+// RegisterEnrichment registers an enrichment type with a stable name, the
+// value is encoded as JSON. Enrichments that are not registered, or that were
+// added using errors.Sensitive, are not encoded. Enrichments of the types
+// defined by the errors package are registered by default.
+func RegisterEnrichment[T any](name string) {
+	codec := enrichmentCodec{
+		name: name,
+		decode: func(data []byte) (errors.Enricher, error) {
+			var value T
+			if err := json.Unmarshal(data, &value); err != nil {
+				return nil, errors.Enrich(ErrInvalidEncoding, errors.Wrapf("failed to decode enrichment %s: %v", name, err))
+			}
+			return errors.Set(value), nil
+		},
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.enrichments[reflect.TypeOf((*T)(nil)).Elem()] = codec
+	registry.names[name] = codec
+}
+
+// Frame is a single frame of a stack trace
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// RemoteError is a decoded error that does not wrap other errors, and was not
+// a sentinel registered by the receiver.
+type RemoteError struct {
+	// Message is the message of the original error
+	Message string
+	// Type is the Go type of the original error
+	Type string
+	// SentinelID is the ID of the original error, if it was registered by the
+	// sender but not by the receiver
+	SentinelID string
+}
+
+func (err *RemoteError) Error() string { return err.Message }
+
+// Is matches the error against sentinels by ID, this allows errors to match
+// sentinels registered by the receiver after the error was decoded.
+func (err *RemoteError) Is(target error) bool {
+	if err.SentinelID == "" {
+		return false
+	}
+
+	id, ok := errors.SentinelID(target)
+	return ok && id == err.SentinelID
+}
+
+type wrapError struct {
+	msg   string
+	typ   string
+	cause error
+}
+
+func (err *wrapError) Error() string { return err.msg }
+func (err *wrapError) Unwrap() error { return err.cause }
+func (err *wrapError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if prefix, ok := strings.CutSuffix(err.msg, ": "+err.cause.Error()); ok && s.Flag('+') {
+			fmt.Fprintf(s, "%s: %+v", prefix, err.cause)
+			return
+		}
+		fallthrough
+	case 's':
+		fmt.Fprint(s, err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", err.Error())
+	}
+}
+
+type stackError struct {
+	frames []Frame
+	cause  error
+}
+
+func (err *stackError) Error() string   { return err.cause.Error() }
+func (err *stackError) Unwrap() error   { return err.cause }
+func (err *stackError) Frames() []Frame { return err.frames }
+func (err *stackError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%+v", err.cause)
+			for _, frame := range err.frames {
+				fmt.Fprintf(s, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+			}
+			return
+		}
+		fallthrough
+	case 's':
+		fmt.Fprint(s, err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", err.Error())
+	}
+}
+
+type aggregateError struct {
+	msg  string
+	typ  string
+	errs []error
+}
+
+func (err *aggregateError) Error() string   { return err.msg }
+func (err *aggregateError) Unwrap() []error { return err.errs }
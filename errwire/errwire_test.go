@@ -0,0 +1,234 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errwire_test
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/errwire"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Detail is a registered test enrichment
+type Detail struct {
+	Code  int
+	Field string
+}
+
+// Unregistered is a test enrichment that is not registered
+type Unregistered string
+
+var ErrNotFound = errors.New("test message 01")
+
+func init() {
+	errors.Register("errwire_test.NotFound", ErrNotFound)
+	errwire.RegisterEnrichment[Detail]("errwire_test.Detail")
+}
+
+func failWithStack() error {
+	return errors.Enrich(errors.New("test message 04"), errors.WithStack())
+}
+
+func roundTrip(err error) error {
+	return decode(errwire.Encode(err))
+}
+
+func decode(data []byte) error {
+	decoded, err := errwire.Decode(data)
+	Expect(err).NotTo(HaveOccurred())
+	return decoded
+}
+
+var _ = Describe("Encode and Decode", func() {
+	It("should encode nil errors", func() {
+		Expect(errwire.Encode(nil)).To(BeNil())
+		Expect(decode(nil)).To(Succeed())
+	})
+
+	It("should decode errors as remote errors", func() {
+		err := roundTrip(errors.New("test message 02"))
+		Expect(err).To(MatchError("test message 02"))
+
+		var remote *errwire.RemoteError
+		Expect(errors.As(err, &remote)).To(BeTrue())
+		Expect(remote.Type).To(Equal("errors.errorString"))
+	})
+
+	It("should rehydrate registered sentinels", func() {
+		err := roundTrip(errors.Enrich(ErrNotFound, errors.Wrap("prefix")))
+		Expect(err).To(MatchError("prefix: test message 01"))
+		Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+	})
+
+	It("should preserve wrapped messages", func() {
+		original := fmt.Errorf("outer %w", errors.Enrich(errors.New("test message 02"), errors.Wrapf("middle %d", 1)))
+		Expect(roundTrip(original)).To(MatchError(original.Error()))
+	})
+
+	It("should preserve registered enrichments", func() {
+		err := roundTrip(errors.Enrich(errors.New("test message 02"),
+			errors.Set(Detail{Code: 5, Field: "name"}),
+			errors.SetCategory(errors.CategoryConflict),
+			errors.Set[errors.Retryable](true),
+			errors.Wrap("prefix"),
+		))
+
+		Expect(err).To(MatchError("prefix: test message 02"))
+		Expect(errors.Get(err, Detail{})).To(Equal(Detail{Code: 5, Field: "name"}))
+		Expect(errors.Classify(err)).To(Equal(errors.CategoryConflict))
+		Expect(errors.IsRetryable(err)).To(BeTrue())
+	})
+
+	It("should drop unregistered and sensitive enrichments", func() {
+		err := roundTrip(errors.Enrich(errors.New("test message 02"),
+			errors.Set[Unregistered]("value"),
+			errors.Sensitive(Detail{Code: 5}),
+		))
+
+		Expect(err).To(MatchError("test message 02"))
+		Expect(errors.All[Unregistered](err)).To(BeEmpty())
+		Expect(errors.All[Detail](err)).To(BeEmpty())
+	})
+
+	It("should preserve stack frames", func() {
+		err := roundTrip(errors.Enrich(failWithStack(), errors.Wrap("prefix")))
+		Expect(err).To(MatchError("prefix: test message 04"))
+
+		var stack interface{ Frames() []errwire.Frame }
+		Expect(errors.As(err, &stack)).To(BeTrue())
+		Expect(stack.Frames()).ToNot(BeEmpty())
+		Expect(stack.Frames()[0].Function).To(Equal("github.com/kubespress/errors/errwire_test.failWithStack"))
+		Expect(stack.Frames()[0].File).To(HaveSuffix("errwire_test.go"))
+		Expect(fmt.Sprintf("%+v", err)).To(HavePrefix("prefix: test message 04\ngithub.com/kubespress/errors/errwire_test.failWithStack\n\t"))
+	})
+
+	It("should preserve aggregates", func() {
+		original := errors.Aggregate(
+			errors.Enrich(ErrNotFound, errors.SetCategory(errors.CategoryNotFound)),
+			errors.New("test message 02"),
+		)
+
+		err := roundTrip(original)
+		Expect(err).To(MatchError(original.Error()))
+		Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+		Expect(errors.Classify(err)).To(Equal(errors.CategoryNotFound))
+	})
+
+	It("should preserve go-multierror errors as aggregates", func() {
+		original := multierror.Append(
+			errors.Enrich(ErrNotFound, errors.SetCategory(errors.CategoryNotFound)),
+			errors.New("test message 02"),
+		)
+
+		err := roundTrip(original)
+		Expect(err).To(MatchError(original.Error()))
+		Expect(err.(interface{ Unwrap() []error }).Unwrap()).To(HaveLen(2))
+		Expect(errors.Is(err, ErrNotFound)).To(BeTrue())
+		Expect(errors.Classify(err)).To(Equal(errors.CategoryNotFound))
+	})
+
+	It("should encode decoded errors identically", func() {
+		original := errwire.Encode(errors.Enrich(errors.Aggregate(
+			errors.Enrich(failWithStack(), errors.Set(Detail{Code: 1})),
+			errors.New("test message 02"),
+			ErrNotFound,
+		), errors.Wrap("prefix")))
+
+		Expect(errwire.Encode(decode(original))).To(Equal(original))
+	})
+
+	It("should keep the ID of sentinels the receiver has not registered", func() {
+		var data []byte
+		data = protowire.AppendTag(data, 1, protowire.BytesType)
+		data = protowire.AppendString(data, "test message 03")
+		data = protowire.AppendTag(data, 4, protowire.BytesType)
+		data = protowire.AppendString(data, "other.Sentinel")
+
+		var remote *errwire.RemoteError
+		Expect(errors.As(decode(data), &remote)).To(BeTrue())
+		Expect(remote.SentinelID).To(Equal("other.Sentinel"))
+		Expect(remote.Message).To(Equal("test message 03"))
+	})
+
+	It("should match sentinels registered after decoding by ID", func() {
+		late := errors.New("test message 05")
+
+		var data []byte
+		data = protowire.AppendTag(data, 1, protowire.BytesType)
+		data = protowire.AppendString(data, "test message 05")
+		data = protowire.AppendTag(data, 4, protowire.BytesType)
+		data = protowire.AppendString(data, "errwire_test.Late")
+
+		err := errors.Enrich(decode(data), errors.Wrap("prefix"))
+		Expect(errors.Is(err, late)).To(BeFalse())
+
+		errors.Register("errwire_test.Late", late)
+		Expect(errors.Is(err, late)).To(BeTrue())
+		Expect(errors.Is(err, ErrNotFound)).To(BeFalse())
+	})
+
+	It("should ignore unknown fields", func() {
+		data := errwire.Encode(errors.New("test message 02"))
+		data = protowire.AppendTag(data, 99, protowire.VarintType)
+		data = protowire.AppendVarint(data, 1)
+		Expect(decode(data)).To(MatchError("test message 02"))
+	})
+
+	It("should reject invalid data", func() {
+		decoded, err := errwire.Decode([]byte{0xff})
+		Expect(decoded).To(BeNil())
+		Expect(errors.Is(err, errwire.ErrInvalidEncoding)).To(BeTrue())
+	})
+
+	It("should reject invalid enrichment values", func() {
+		var enrichment []byte
+		enrichment = protowire.AppendTag(enrichment, 1, protowire.BytesType)
+		enrichment = protowire.AppendString(enrichment, "errwire_test.Detail")
+		enrichment = protowire.AppendTag(enrichment, 2, protowire.BytesType)
+		enrichment = protowire.AppendString(enrichment, "{")
+
+		var data []byte
+		data = protowire.AppendTag(data, 2, protowire.VarintType)
+		data = protowire.AppendVarint(data, 2)
+		data = protowire.AppendTag(data, 5, protowire.BytesType)
+		data = protowire.AppendBytes(data, enrichment)
+		data = protowire.AppendTag(data, 7, protowire.BytesType)
+		data = protowire.AppendBytes(data, errwire.Encode(errors.New("test message 02")))
+
+		decoded, err := errwire.Decode(data)
+		Expect(decoded).To(BeNil())
+		Expect(errors.Is(err, errwire.ErrInvalidEncoding)).To(BeTrue())
+	})
+
+	It("should reject deeply nested data", func() {
+		data := errwire.Encode(errors.New("test message 02"))
+		for i := 0; i < 2000; i++ {
+			var wrapped []byte
+			wrapped = protowire.AppendTag(wrapped, 2, protowire.VarintType)
+			wrapped = protowire.AppendVarint(wrapped, 1)
+			wrapped = protowire.AppendTag(wrapped, 7, protowire.BytesType)
+			data = protowire.AppendBytes(wrapped, data)
+		}
+
+		_, err := errwire.Decode(data)
+		Expect(errors.Is(err, errwire.ErrInvalidEncoding)).To(BeTrue())
+	})
+})
@@ -0,0 +1,186 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// Fault is the enrichment added to errors returned by an armed injection
+// point, its value is the name of the injection point.
+type Fault string
+
+// FaultOption configures a fault armed on a FaultRegistry
+type FaultOption func(*fault)
+
+// FaultProbability sets the probability, between 0 and 1, that the fault is
+// returned each time the injection point is reached. The default is 1.
+func FaultProbability(probability float64) FaultOption {
+	return func(f *fault) {
+		f.probability = probability
+	}
+}
+
+// FaultLimit limits the number of times the fault is returned, once reached
+// the injection point returns nil.
+func FaultLimit(count int) FaultOption {
+	return func(f *fault) {
+		f.limit = count
+	}
+}
+
+// FaultAfter skips the first calls to the injection point, the fault is only
+// returned once the injection point has been reached more than count times.
+func FaultAfter(count int) FaultOption {
+	return func(f *fault) {
+		f.after = count
+	}
+}
+
+// FaultEnrich enriches the error returned by the injection point
+func FaultEnrich(enrichments ...Enricher) FaultOption {
+	return func(f *fault) {
+		f.enrichments = append(f.enrichments, enrichments...)
+	}
+}
+
+type fault struct {
+	err         error
+	probability float64
+	limit       int
+	after       int
+	calls       int
+	returned    int
+	enrichments []Enricher
+}
+
+// FaultRegistry holds the faults armed at named injection points. Tests that
+// run in parallel should create their own registry and scope it using
+// WithFaults, rather than using DefaultFaults.
+type FaultRegistry struct {
+	armed  atomic.Int32
+	lock   sync.Mutex
+	faults map[string]*fault
+}
+
+// DefaultFaults is the registry used by Inject, and by InjectContext when the
+// context does not have a registry.
+var DefaultFaults = NewFaultRegistry()
+
+// NewFaultRegistry returns a registry with no armed faults
+func NewFaultRegistry() *FaultRegistry {
+	return &FaultRegistry{
+		faults: map[string]*fault{},
+	}
+}
+
+// Arm configures the injection point to return the error. Arming an injection
+// point that is already armed replaces the fault.
+func (r *FaultRegistry) Arm(name string, err error, opts ...FaultOption) {
+	f := &fault{err: err, probability: 1, limit: -1}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.faults[name] = f
+	r.armed.Store(int32(len(r.faults)))
+}
+
+// Disarm removes the fault from the injection point
+func (r *FaultRegistry) Disarm(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.faults, name)
+	r.armed.Store(int32(len(r.faults)))
+}
+
+// Reset disarms all injection points
+func (r *FaultRegistry) Reset() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.faults = map[string]*fault{}
+	r.armed.Store(0)
+}
+
+// Inject returns the error armed at the injection point, or nil if the
+// injection point is not armed. Returned errors are enriched with the Fault
+// enrichment.
+func (r *FaultRegistry) Inject(name string) error {
+	// Fast path for when nothing is armed, such as in production
+	if r.armed.Load() == 0 {
+		return nil
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Check the fault is armed
+	f, ok := r.faults[name]
+	if !ok {
+		return nil
+	}
+
+	// Check the fault should be returned on this call
+	f.calls++
+	switch {
+	case f.calls <= f.after:
+		return nil
+	case f.limit >= 0 && f.returned >= f.limit:
+		return nil
+	case f.probability < 1 && rand.Float64() >= f.probability:
+		return nil
+	}
+
+	f.returned++
+	return Enrich(Enrich(f.err, f.enrichments...), Set(Fault(name)))
+}
+
+// Inject is a named injection point, it returns the error armed on
+// DefaultFaults. In production nothing is armed so it always returns nil,
+// for example:
+//
+//	if err := errors.Inject("db.write"); err != nil {
+//		return err
+//	}
+func Inject(name string) error {
+	return DefaultFaults.Inject(name)
+}
+
+type faultsContextKey struct{}
+
+// WithFaults returns a context that uses the registry for InjectContext
+func WithFaults(ctx context.Context, registry *FaultRegistry) context.Context {
+	return context.WithValue(ctx, faultsContextKey{}, registry)
+}
+
+// InjectContext is a named injection point, it returns the error armed on the
+// registry of the context, see WithFaults. If the context does not have a
+// registry DefaultFaults is used.
+func InjectContext(ctx context.Context, name string) error {
+	if registry, ok := ctx.Value(faultsContextKey{}).(*FaultRegistry); ok {
+		return registry.Inject(name)
+	}
+	return DefaultFaults.Inject(name)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"context"
+
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Fault injection", func() {
+	var sentinel = errors.New("test message 01")
+	var registry *errors.FaultRegistry
+
+	BeforeEach(func() {
+		registry = errors.NewFaultRegistry()
+	})
+
+	inject := func(name string, n int) (count int) {
+		for i := 0; i < n; i++ {
+			if registry.Inject(name) != nil {
+				count++
+			}
+		}
+		return count
+	}
+
+	It("should return nil if nothing is armed", func() {
+		Expect(errors.Inject("test.point")).ToNot(HaveOccurred())
+		Expect(registry.Inject("test.point")).ToNot(HaveOccurred())
+	})
+
+	It("should return the armed error", func() {
+		registry.Arm("test.point", sentinel, errors.FaultEnrich(errors.Wrap("injected")))
+
+		err := registry.Inject("test.point")
+		Expect(err).To(MatchError("injected: test message 01"))
+		Expect(errors.Is(err, sentinel)).To(BeTrue())
+		Expect(errors.Get[errors.Fault](err, "")).To(Equal(errors.Fault("test.point")))
+		Expect(registry.Inject("other.point")).ToNot(HaveOccurred())
+	})
+
+	It("should disarm faults", func() {
+		registry.Arm("test.point", sentinel)
+		registry.Arm("other.point", sentinel)
+
+		registry.Disarm("test.point")
+		Expect(registry.Inject("test.point")).ToNot(HaveOccurred())
+		Expect(registry.Inject("other.point")).To(HaveOccurred())
+
+		registry.Reset()
+		Expect(registry.Inject("other.point")).ToNot(HaveOccurred())
+	})
+
+	It("should limit the number of faults", func() {
+		registry.Arm("test.point", sentinel, errors.FaultLimit(2))
+		Expect(inject("test.point", 5)).To(Equal(2))
+	})
+
+	It("should skip calls", func() {
+		registry.Arm("test.point", sentinel, errors.FaultAfter(2), errors.FaultLimit(1))
+		Expect(registry.Inject("test.point")).ToNot(HaveOccurred())
+		Expect(registry.Inject("test.point")).ToNot(HaveOccurred())
+		Expect(registry.Inject("test.point")).To(HaveOccurred())
+		Expect(registry.Inject("test.point")).ToNot(HaveOccurred())
+	})
+
+	It("should respect the probability", func() {
+		registry.Arm("never", sentinel, errors.FaultProbability(0))
+		registry.Arm("sometimes", sentinel, errors.FaultProbability(0.5))
+		Expect(inject("never", 1000)).To(BeZero())
+		Expect(inject("sometimes", 1000)).To(BeNumerically("~", 500, 150))
+	})
+
+	It("should use the registry from the context", func() {
+		registry.Arm("test.point", sentinel)
+		ctx := errors.WithFaults(context.Background(), registry)
+
+		Expect(errors.InjectContext(ctx, "test.point")).To(MatchError(sentinel))
+		Expect(errors.InjectContext(context.Background(), "test.point")).ToNot(HaveOccurred())
+	})
+
+	It("should use the default registry", func() {
+		errors.DefaultFaults.Arm("test.point", sentinel)
+		DeferCleanup(errors.DefaultFaults.Reset)
+
+		Expect(errors.Inject("test.point")).To(MatchError(sentinel))
+		Expect(errors.InjectContext(context.Background(), "test.point")).To(MatchError(sentinel))
+	})
+})
@@ -0,0 +1,243 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Path is the path to a field, for example "spec.containers[0].image". It is
+// built using Field and the Child, Index and Key methods.
+type Path struct {
+	name   string
+	index  string
+	parent *Path
+}
+
+// Field returns the path to a top level field, additional names are treated
+// as children of the first.
+func Field(name string, moreNames ...string) *Path {
+	path := &Path{name: name}
+	for _, name := range moreNames {
+		path = &Path{name: name, parent: path}
+	}
+	return path
+}
+
+// Child returns the path to a child field, additional names are treated as
+// children of the first.
+func (p *Path) Child(name string, moreNames ...string) *Path {
+	path := Field(name, moreNames...)
+	path.Root().parent = p
+	return path
+}
+
+// Index returns the path to an element of a list.
+func (p *Path) Index(index int) *Path {
+	return &Path{index: strconv.Itoa(index), parent: p}
+}
+
+// Key returns the path to an element of a map.
+func (p *Path) Key(key string) *Path {
+	return &Path{index: key, parent: p}
+}
+
+// Root returns the top level field of the path.
+func (p *Path) Root() *Path {
+	for ; p.parent != nil; p = p.parent {
+	}
+	return p
+}
+
+// String returns the path, for example "spec.containers[0].image".
+func (p *Path) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+
+	// Collect the elements from the root
+	var elems []*Path
+	for ; p != nil; p = p.parent {
+		elems = append(elems, p)
+	}
+
+	var buf strings.Builder
+	for i := len(elems) - 1; i >= 0; i-- {
+		p := elems[i]
+		if p.parent != nil && len(p.name) > 0 {
+			buf.WriteString(".")
+		}
+		if len(p.name) > 0 {
+			buf.WriteString(p.name)
+		} else {
+			buf.WriteString("[" + p.index + "]")
+		}
+	}
+
+	return buf.String()
+}
+
+// FieldErrorType is the type of a field error, it is added to field errors as
+// an enrichment.
+type FieldErrorType string
+
+const (
+	// FieldValueInvalid indicates the value of a field is invalid
+	FieldValueInvalid FieldErrorType = "FieldValueInvalid"
+
+	// FieldValueRequired indicates a required field was not set
+	FieldValueRequired FieldErrorType = "FieldValueRequired"
+
+	// FieldValueForbidden indicates a field cannot be set
+	FieldValueForbidden FieldErrorType = "FieldValueForbidden"
+
+	// FieldValueDuplicate indicates a value was duplicated where values must be
+	// unique
+	FieldValueDuplicate FieldErrorType = "FieldValueDuplicate"
+)
+
+// String returns a human readable description of the error type
+func (t FieldErrorType) String() string {
+	switch t {
+	case FieldValueInvalid:
+		return "Invalid value"
+	case FieldValueRequired:
+		return "Required value"
+	case FieldValueForbidden:
+		return "Forbidden"
+	case FieldValueDuplicate:
+		return "Duplicate value"
+	default:
+		return string(t)
+	}
+}
+
+// BadValue is the value of a field that caused a field error, it is added to
+// field errors as an enrichment.
+type BadValue struct {
+	Value any
+}
+
+// fieldDetail is the message of a field error, excluding the path
+type fieldDetail string
+
+// Invalid returns a field error indicating the value of the field is invalid.
+func Invalid(path *Path, value any, detail string) error {
+	return newFieldError(path, FieldValueInvalid, value, true, detail)
+}
+
+// Required returns a field error indicating a required field was not set.
+func Required(path *Path, detail string) error {
+	return newFieldError(path, FieldValueRequired, nil, false, detail)
+}
+
+// Forbidden returns a field error indicating the field cannot be set.
+func Forbidden(path *Path, detail string) error {
+	return newFieldError(path, FieldValueForbidden, nil, false, detail)
+}
+
+// Duplicate returns a field error indicating the value of the field is
+// duplicated.
+func Duplicate(path *Path, value any) error {
+	return newFieldError(path, FieldValueDuplicate, value, true, "")
+}
+
+func newFieldError(path *Path, errorType FieldErrorType, value any, hasValue bool, detail string) error {
+	// Build the message
+	format, args := "%s", []any{errorType}
+	if hasValue {
+		format, args = format+": %#v", append(args, value)
+	}
+	if detail != "" {
+		format, args = format+": %s", append(args, detail)
+	}
+	err := Errorf(format, args...)
+
+	// Add the enrichments
+	enrichments := []Enricher{
+		Set(fieldDetail(err.Error())),
+		Set(errorType),
+		Set(path),
+	}
+	if hasValue {
+		enrichments = append(enrichments, Set(BadValue{Value: value}))
+	}
+
+	return Enrich(err, append(enrichments, Wrap(path.String()))...)
+}
+
+// FieldErrorDetail returns the message of a field error excluding the path,
+// for example "Invalid value: -1: must be positive". If the error is not a
+// field error an empty string is returned.
+func FieldErrorDetail(err error) string {
+	return string(Get[fieldDetail](err, ""))
+}
+
+// FieldErrorGroup is a set of field errors sharing the same path.
+type FieldErrorGroup struct {
+	// Path is the path of the field
+	Path string
+
+	// Errors are the errors for the field
+	Errors []error
+}
+
+// GroupFieldErrors returns the field errors within the error grouped by path.
+// The groups are in the order each path first appears. Errors that are not
+// field errors are ignored.
+func GroupFieldErrors(err error) []FieldErrorGroup {
+	var groups []FieldErrorGroup
+	indexes := map[string]int{}
+
+	for _, err := range branches(err) {
+		path := Get[*Path](err, nil)
+		if path == nil {
+			continue
+		}
+
+		// Create a new group if this is the first time the path is seen
+		index, ok := indexes[path.String()]
+		if !ok {
+			index = len(groups)
+			indexes[path.String()] = index
+			groups = append(groups, FieldErrorGroup{Path: path.String()})
+		}
+
+		groups[index].Errors = append(groups[index].Errors, err)
+	}
+
+	return groups
+}
+
+// FormatFieldErrors renders the field errors within the error, one line per
+// path, for example:
+//
+// spec.replicas: Invalid value: -1: must be positive, Duplicate value: -1
+func FormatFieldErrors(err error) string {
+	var lines []string
+	for _, group := range GroupFieldErrors(err) {
+		details := make([]string, 0, len(group.Errors))
+		for _, err := range group.Errors {
+			details = append(details, FieldErrorDetail(err))
+		}
+
+		lines = append(lines, group.Path+": "+strings.Join(details, ", "))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Path", func() {
+	It("should render the path", func() {
+		Expect(errors.Field("spec").String()).To(Equal("spec"))
+		Expect(errors.Field("spec", "replicas").String()).To(Equal("spec.replicas"))
+		Expect(errors.Field("spec").Child("containers").Index(0).Child("image").String()).To(Equal("spec.containers[0].image"))
+		Expect(errors.Field("metadata").Child("labels").Key("app").String()).To(Equal("metadata.labels[app]"))
+		Expect(errors.Field("spec").Child("template", "spec").String()).To(Equal("spec.template.spec"))
+	})
+})
+
+var _ = Describe("Field errors", func() {
+	var path = errors.Field("spec", "replicas")
+
+	DescribeTable("should carry the path, type and value",
+		func(err error, message string, errorType errors.FieldErrorType, value *errors.BadValue) {
+			Expect(err).To(MatchError(message))
+			Expect(errors.Get[*errors.Path](err, nil)).To(Equal(path))
+			Expect(errors.Get[errors.FieldErrorType](err, "")).To(Equal(errorType))
+			if value != nil {
+				Expect(errors.All[errors.BadValue](err)).To(Equal([]errors.BadValue{*value}))
+			} else {
+				Expect(errors.All[errors.BadValue](err)).To(BeEmpty())
+			}
+		},
+		Entry("invalid", errors.Invalid(path, -1, "must be positive"), "spec.replicas: Invalid value: -1: must be positive", errors.FieldValueInvalid, &errors.BadValue{Value: -1}),
+		Entry("invalid string", errors.Invalid(path, "a", ""), `spec.replicas: Invalid value: "a"`, errors.FieldValueInvalid, &errors.BadValue{Value: "a"}),
+		Entry("required", errors.Required(path, ""), "spec.replicas: Required value", errors.FieldValueRequired, nil),
+		Entry("forbidden", errors.Forbidden(path, "cannot be set"), "spec.replicas: Forbidden: cannot be set", errors.FieldValueForbidden, nil),
+		Entry("duplicate", errors.Duplicate(path, 3), "spec.replicas: Duplicate value: 3", errors.FieldValueDuplicate, &errors.BadValue{Value: 3}),
+	)
+
+	Context("when aggregated", func() {
+		var err error
+
+		BeforeEach(func() {
+			var errs errors.ErrorList
+			errs = append(errs,
+				errors.Invalid(path, -1, "must be positive"),
+				errors.Required(errors.Field("spec", "selector"), ""),
+				errors.New("test message 17"),
+				errors.Duplicate(path, -1),
+			)
+			err = errors.Enrich(errs.Error(), errors.Wrap("validation failed"))
+		})
+
+		It("should group the errors by path", func() {
+			groups := errors.GroupFieldErrors(err)
+			Expect(groups).To(HaveLen(2))
+			Expect(groups[0].Path).To(Equal("spec.replicas"))
+			Expect(groups[0].Errors).To(HaveLen(2))
+			Expect(groups[1].Path).To(Equal("spec.selector"))
+			Expect(groups[1].Errors).To(HaveLen(1))
+		})
+
+		It("should render the errors by path", func() {
+			Expect(errors.FormatFieldErrors(err)).To(Equal("spec.replicas: Invalid value: -1: must be positive, Duplicate value: -1\nspec.selector: Required value"))
+		})
+	})
+})
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+
+	"github.com/kubespress/errors/internal/errtree"
+)
+
+// FingerprintComponent selects a part of an error that contributes to its
+// fingerprint. Components can be combined using a bitwise or.
+type FingerprintComponent uint
+
+const (
+	// FingerprintType includes the type of the innermost errors
+	FingerprintType FingerprintComponent = 1 << iota
+
+	// FingerprintSentinel includes the identity of sentinel errors created
+	// using New, or the ID of sentinels registered using Register
+	FingerprintSentinel
+
+	// FingerprintWrap includes the templates of messages added using Wrap,
+	// Wrapf and Errorf, the formatted arguments are not included
+	FingerprintWrap
+
+	// FingerprintStack includes the function names of the top frames of call
+	// stacks added using WithStack
+	FingerprintStack
+
+	// FingerprintAll includes all the components
+	FingerprintAll = FingerprintType | FingerprintSentinel | FingerprintWrap | FingerprintStack
+)
+
+// fingerprintStackFrames is the number of frames from the top of each stack
+// that are included in the fingerprint
+const fingerprintStackFrames = 3
+
+// Fingerprint returns a stable hash of the error that can be used to group
+// identical failures, for example across replicas of the same service. The
+// fingerprint does not depend on dynamic values such as the arguments passed to
+// Wrapf. If no components are provided FingerprintAll is used. If err is nil an
+// empty string is returned.
+func Fingerprint(err error, components ...FingerprintComponent) string {
+	if err == nil {
+		return ""
+	}
+
+	// Combine the requested components
+	var selected FingerprintComponent
+	for _, component := range components {
+		selected |= component
+	}
+	if len(components) == 0 {
+		selected = FingerprintAll
+	}
+
+	// Hash the error, each value is terminated with a null byte so adjacent
+	// values cannot run together and collide
+	h := sha256.New()
+	write := func(kind string, value string) {
+		fmt.Fprintf(h, "%s:%s\x00", kind, value)
+	}
+
+	_ = Walker{
+		Pre: func(node WalkNode) error {
+			fingerprint(write, node.Err, selected)
+			return nil
+		},
+		Post: func(node WalkNode) error {
+			if errtree.Multiple(node.Err) {
+				write("aggregate", "]")
+			}
+			return nil
+		},
+	}.Walk(err)
+
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// fingerprint writes the components of a single error, the errors it wraps are
+// written by the caller. Wrappers, including enrichments, do not contribute to
+// the fingerprint unless they are listed below.
+func fingerprint(write func(string, string), err error, components FingerprintComponent) {
+	// Include the templates of errors created using Wrap, Wrapf and Errorf
+	if templated, ok := err.(interface{ Template() (string, []any) }); ok && components&FingerprintWrap != 0 {
+		format, _ := templated.Template()
+		write("template", format)
+	}
+
+	// Include the top frames of stacks
+	if e, ok := err.(errWithStack); ok && components&FingerprintStack != 0 {
+		frames := runtime.CallersFrames(e.stack)
+		for i := 0; i < fingerprintStackFrames; i++ {
+			frame, more := frames.Next()
+			write("frame", frame.Function)
+			if !more {
+				break
+			}
+		}
+	}
+
+	switch {
+	case errtree.Multiple(err):
+		write("aggregate", "[")
+	case len(errtree.Children(err)) == 0:
+		fingerprintCause(write, err, components)
+	}
+}
+
+func fingerprintCause(write func(string, string), err error, components FingerprintComponent) {
+	if components&FingerprintType != 0 {
+		write("type", fmt.Sprintf("%T", err))
+	}
+
+	if components&FingerprintSentinel == 0 {
+		return
+	}
+
+	// Registered sentinels are identified by their ID, errors created using New
+	// have a constant message, so it can be used to identify them
+	if id, ok := SentinelID(err); ok {
+		write("sentinel", id)
+	} else if sentinel, ok := err.(errorString); ok {
+		write("sentinel", sentinel.msg)
+	}
+}
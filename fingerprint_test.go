@@ -0,0 +1,81 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func failWithStackA(err error) error {
+	return errors.Enrich(err, errors.WithStack())
+}
+
+func failWithStackB(err error) error {
+	return errors.Enrich(err, errors.WithStack())
+}
+
+var _ = Describe("Fingerprint", func() {
+	var sentinel1 = errors.New("test message 06")
+	var sentinel2 = errors.New("test message 07")
+
+	It("should return an empty string for nil errors", func() {
+		Expect(errors.Fingerprint(nil)).To(BeEmpty())
+	})
+
+	It("should ignore the arguments passed to Wrapf", func() {
+		err1 := errors.Enrich(sentinel1, errors.Wrapf("failed to get %s", "object-1"))
+		err2 := errors.Enrich(sentinel1, errors.Wrapf("failed to get %s", "object-2"))
+		Expect(err1).ToNot(MatchError(err2.Error()))
+		Expect(errors.Fingerprint(err1)).To(Equal(errors.Fingerprint(err2)))
+	})
+
+	It("should ignore enrichments", func() {
+		type Context string
+		err1 := errors.Enrich(sentinel1, errors.Set[Context]("value 1"))
+		err2 := errors.Enrich(sentinel1, errors.Set[Context]("value 2"))
+		Expect(errors.Fingerprint(err1)).To(Equal(errors.Fingerprint(err2)))
+	})
+
+	It("should distinguish wrap templates", func() {
+		err1 := errors.Enrich(sentinel1, errors.Wrap("failed to get object"))
+		err2 := errors.Enrich(sentinel1, errors.Wrap("failed to update object"))
+		Expect(errors.Fingerprint(err1)).ToNot(Equal(errors.Fingerprint(err2)))
+		Expect(errors.Fingerprint(err1, errors.FingerprintType, errors.FingerprintSentinel)).To(Equal(errors.Fingerprint(err2, errors.FingerprintType, errors.FingerprintSentinel)))
+	})
+
+	It("should distinguish sentinels", func() {
+		Expect(errors.Fingerprint(sentinel1)).ToNot(Equal(errors.Fingerprint(sentinel2)))
+		Expect(errors.Fingerprint(sentinel1, errors.FingerprintType)).To(Equal(errors.Fingerprint(sentinel2, errors.FingerprintType)))
+	})
+
+	It("should distinguish stacks", func() {
+		err1 := failWithStackA(sentinel1)
+		err2 := failWithStackB(sentinel1)
+		Expect(errors.Fingerprint(err1)).To(Equal(errors.Fingerprint(failWithStackA(sentinel1))))
+		Expect(errors.Fingerprint(err1)).ToNot(Equal(errors.Fingerprint(err2)))
+		Expect(errors.Fingerprint(err1, errors.FingerprintType, errors.FingerprintSentinel)).To(Equal(errors.Fingerprint(err2, errors.FingerprintType, errors.FingerprintSentinel)))
+	})
+
+	It("should include each aggregated error", func() {
+		err1 := errors.Aggregate(sentinel1, errors.Enrich(sentinel2, errors.Wrapf("id %d", 1)))
+		err2 := errors.Aggregate(sentinel1, errors.Enrich(sentinel2, errors.Wrapf("id %d", 2)))
+		Expect(errors.Fingerprint(err1)).To(Equal(errors.Fingerprint(err2)))
+		Expect(errors.Fingerprint(err1)).ToNot(Equal(errors.Fingerprint(errors.Aggregate(sentinel1, sentinel2))))
+	})
+})
@@ -0,0 +1,253 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type formattedError struct {
+	msg     string
+	plain   string
+	verbose string
+	format  string
+	args    []any
+}
+
+func (err *formattedError) Error() string             { return err.msg }
+func (err *formattedError) Template() (string, []any) { return err.format, err.args }
+func (err *formattedError) LogValue() slog.Value {
+	return templateLogValue(err.msg, err.format, err.args)
+}
+func (err *formattedError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, err.verbose, err.args...)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", err.Error())
+	}
+}
+
+// formattedWrapError is a formattedError wrapping a single error using %w
+type formattedWrapError struct {
+	*formattedError
+	err error
+}
+
+func (err *formattedWrapError) Unwrap() error { return err.err }
+
+// formattedWrapErrors is a formattedError wrapping multiple errors using %w
+type formattedWrapErrors struct {
+	*formattedError
+	errs []error
+}
+
+func (err *formattedWrapErrors) Unwrap() []error { return err.errs }
+
+// Errorf creates a new error using string formatting. As with fmt.Errorf the
+// %w verb can be used to wrap one or more errors. When formatted using %+v the
+// wrapped errors are also formatted using %+v, so details such as stack traces
+// are not lost. The format template and arguments are preserved, see Template.
+// As with fmt.Errorf a pointer is returned, so each error is only equal to
+// itself and can be used as a sentinel.
+func Errorf(msg string, args ...any) error {
+	plain, verbose, wrapped := parseTemplate(msg, args)
+	err := &formattedError{
+		msg:     fmt.Sprintf(plain, args...),
+		plain:   plain,
+		verbose: verbose,
+		format:  msg,
+		args:    args,
+	}
+
+	// Output depends on the number of wrapped errors
+	switch len(wrapped) {
+	case 0:
+		return err
+	case 1:
+		return &formattedWrapError{formattedError: err, err: wrapped[0]}
+	default:
+		return &formattedWrapErrors{formattedError: err, errs: wrapped}
+	}
+}
+
+// parseTemplate rewrites each %w verb in format whose argument is an error to a
+// %v verb, as understood by fmt.Sprintf. The verbose template additionally has
+// the + flag set so wrapped errors are formatted using %+v. The wrapped errors
+// are returned in argument order, without duplicates. Verbs whose argument is
+// not an error are left in place so they are reported as a bad verb, this
+// mirrors the behaviour of fmt.Errorf.
+func parseTemplate(format string, args []any) (plain, verbose string, wrapped []error) {
+	var plainBuf, verboseBuf strings.Builder
+	var indexes []int
+	argNum := 0
+
+	// argIndex parses an explicit argument index such as [2] at the start of
+	// the string, returning the number of bytes consumed
+	argIndex := func(s string) int {
+		if len(s) < 3 || s[0] != '[' {
+			return 0
+		}
+		for i := 1; i < len(s); i++ {
+			if s[i] == ']' {
+				if n, err := strconv.Atoi(s[1:i]); err == nil && n > 0 {
+					argNum = n - 1
+				}
+				return i + 1
+			}
+		}
+		return 0
+	}
+
+	for i := 0; i < len(format); {
+		// Copy everything up to the next verb
+		next := strings.IndexByte(format[i:], '%')
+		if next < 0 {
+			plainBuf.WriteString(format[i:])
+			verboseBuf.WriteString(format[i:])
+			break
+		}
+		plainBuf.WriteString(format[i : i+next])
+		verboseBuf.WriteString(format[i : i+next])
+		start := i + next
+		j := start + 1
+
+		// Flags
+		for j < len(format) && strings.IndexByte("+-# 0", format[j]) >= 0 {
+			j++
+		}
+
+		// Width and precision, which can consume arguments using *
+		for _, precision := range []bool{false, true} {
+			if precision {
+				if j >= len(format) || format[j] != '.' {
+					break
+				}
+				j++
+			}
+			j += argIndex(format[j:])
+			if j < len(format) && format[j] == '*' {
+				argNum++
+				j++
+			}
+			for j < len(format) && format[j] >= '0' && format[j] <= '9' {
+				j++
+			}
+		}
+		j += argIndex(format[j:])
+
+		// Missing verb, copy the remainder as is
+		if j >= len(format) {
+			plainBuf.WriteString(format[start:])
+			verboseBuf.WriteString(format[start:])
+			break
+		}
+
+		verb, size := utf8.DecodeRuneInString(format[j:])
+		end := j + size
+		switch {
+		case verb == '%':
+			// Literal percent sign, no argument is consumed
+			plainBuf.WriteString(format[start:end])
+			verboseBuf.WriteString(format[start:end])
+		case verb == 'w' && argNum < len(args) && isError(args[argNum]):
+			indexes = append(indexes, argNum)
+			plainBuf.WriteString(format[start:j] + "v")
+			verboseBuf.WriteString("%+" + format[start+1:j] + "v")
+			argNum++
+		default:
+			plainBuf.WriteString(format[start:end])
+			verboseBuf.WriteString(format[start:end])
+			argNum++
+		}
+		i = end
+	}
+
+	// Return the wrapped errors in argument order, without duplicates
+	sort.Ints(indexes)
+	for i, index := range indexes {
+		if i > 0 && indexes[i-1] == index {
+			continue
+		}
+		wrapped = append(wrapped, args[index].(error))
+	}
+
+	return plainBuf.String(), verboseBuf.String(), wrapped
+}
+
+func isError(arg any) bool {
+	_, ok := arg.(error)
+	return ok
+}
+
+// Template returns the format template and arguments of the outermost error
+// created using Errorf, Wrapf or Wrap in the error chain. If there is no such
+// error ok is false.
+func Template(err error) (format string, args []any, ok bool) {
+	var templated interface {
+		error
+		Template() (string, []any)
+	}
+	if As(err, &templated) {
+		format, args = templated.Template()
+		return format, args, true
+	}
+
+	return "", nil, false
+}
+
+// chainLogValue returns the structured representation of the outermost error
+// created from a template in the chain of err, so templates are logged when
+// they are enriched or have a stack. Aggregates are not searched, as the
+// template of an aggregated error does not describe the aggregate.
+func chainLogValue(err error) slog.Value {
+	for nested, depth := err, 0; nested != nil && depth <= MaxDepth; nested, depth = unwrap(nested), depth+1 {
+		if templated, ok := nested.(interface{ Template() (string, []any) }); ok {
+			format, args := templated.Template()
+			return templateLogValue(err.Error(), format, args)
+		}
+	}
+
+	return slog.StringValue(err.Error())
+}
+
+// templateLogValue returns a structured representation of an error that was
+// created from a template, this allows structured loggers to emit the
+// arguments as fields.
+func templateLogValue(msg, format string, args []any) slog.Value {
+	attrs := []slog.Attr{
+		slog.String("msg", msg),
+		slog.String("template", format),
+	}
+	if len(args) > 0 {
+		attrs = append(attrs, slog.Any("args", args))
+	}
+
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type countingStringer struct {
+	calls *int
+}
+
+func (s countingStringer) String() string {
+	*s.calls++
+	return "counted"
+}
+
+var _ = Describe("Template", func() {
+	var sentinel = errors.New("test message 08")
+
+	It("should return false for errors without a template", func() {
+		_, _, ok := errors.Template(sentinel)
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should return the template and arguments passed to Wrapf", func() {
+		err := errors.Enrich(sentinel, errors.Wrapf("failed to get %s/%d", "object", 3))
+		format, args, ok := errors.Template(err)
+		Expect(ok).To(BeTrue())
+		Expect(format).To(Equal("failed to get %s/%d"))
+		Expect(args).To(Equal([]any{"object", 3}))
+		Expect(err).To(MatchError("failed to get object/3: test message 08"))
+	})
+
+	It("should return the template and arguments passed to Errorf", func() {
+		err := errors.Errorf("failed to get %s: %w", "object", sentinel)
+		format, args, ok := errors.Template(err)
+		Expect(ok).To(BeTrue())
+		Expect(format).To(Equal("failed to get %s: %w"))
+		Expect(args).To(Equal([]any{"object", sentinel}))
+	})
+
+	It("should return the outermost template", func() {
+		err := errors.Enrich(errors.Errorf("inner %d", 1), errors.Wrapf("outer %d", 2))
+		format, args, _ := errors.Template(err)
+		Expect(format).To(Equal("outer %d"))
+		Expect(args).To(Equal([]any{2}))
+	})
+})
+
+var _ = Describe("Errorf", func() {
+	var sentinel1 = errors.New("test message 09")
+	var sentinel2 = errors.New("test message 10")
+
+	DescribeTable("should produce the same message as fmt.Errorf",
+		func(format string, args ...any) {
+			Expect(errors.Errorf(format, args...)).To(MatchError(fmt.Errorf(format, args...).Error()))
+		},
+		Entry("no verbs", "message"),
+		Entry("literal percent", "100%% %w", sentinel1),
+		Entry("flags and width", "%-8s|%+d|%08.3f: %w", "abc", 1, 1.5, sentinel1),
+		Entry("star width", "%*d: %w", 4, 1, sentinel1),
+		Entry("explicit index", "%[2]w %[1]s", "abc", sentinel1),
+		Entry("repeated index", "%w %[1]w", sentinel1),
+		Entry("non error argument", "%w", "abc"),
+		Entry("nil argument", "%w", nil),
+		Entry("missing argument", "%s %w", "abc"),
+		Entry("missing verb", "%w %", sentinel1),
+	)
+
+	It("should unwrap a single wrapped error", func() {
+		err := errors.Errorf("prefix: %w", sentinel1)
+		Expect(err).To(MatchError("prefix: test message 09"))
+		Expect(errors.Is(err, sentinel1)).To(BeTrue())
+		Expect(err.(interface{ Unwrap() error }).Unwrap()).To(Equal(sentinel1))
+	})
+
+	It("should unwrap multiple wrapped errors", func() {
+		err := errors.Errorf("%w and %w", sentinel1, sentinel2)
+		Expect(err).To(MatchError("test message 09 and test message 10"))
+		Expect(errors.Is(err, sentinel1)).To(BeTrue())
+		Expect(errors.Is(err, sentinel2)).To(BeTrue())
+		Expect(err.(interface{ Unwrap() []error }).Unwrap()).To(Equal([]error{sentinel1, sentinel2}))
+	})
+
+	It("should not unwrap errors formatted with %v", func() {
+		err := errors.Errorf("prefix: %v", sentinel1)
+		Expect(err).To(MatchError("prefix: test message 09"))
+		Expect(errors.Is(err, sentinel1)).To(BeFalse())
+	})
+
+	It("should format wrapped errors using %+v", func() {
+		err := errors.Errorf("first: %w, second: %w", errors.Enrich(sentinel1, errors.WithStack()), sentinel2)
+		Expect(fmt.Sprintf("%+v", err)).To(MatchRegexp("^first: test message 09\n.*\n\t.*/format_test.go:[0-9]+\n(?s:.*), second: test message 10$"))
+		Expect(fmt.Sprintf("%v", err)).To(Equal("first: test message 09, second: test message 10"))
+		Expect(fmt.Sprintf("%s", err)).To(Equal("first: test message 09, second: test message 10"))
+		Expect(fmt.Sprintf("%q", err)).To(Equal(`"first: test message 09, second: test message 10"`))
+	})
+
+	It("should expose enrichments of all wrapped errors", func() {
+		type Context1 string
+		type Context2 string
+		err := errors.Errorf("%w and %w",
+			errors.Enrich(sentinel1, errors.Set[Context1]("value 1")),
+			errors.Enrich(sentinel2, errors.Set[Context2]("value 2")),
+		)
+		Expect(errors.Get[Context1](err, "")).To(Equal(Context1("value 1")))
+		Expect(errors.Get[Context2](err, "")).To(Equal(Context2("value 2")))
+
+		var visited []error
+		errors.Visit(err, func(err error) bool {
+			visited = append(visited, err)
+			return true
+		})
+		Expect(visited).To(HaveLen(5))
+		Expect(visited[2]).To(Equal(sentinel1))
+		Expect(visited[4]).To(Equal(sentinel2))
+	})
+
+	It("should be treated as a single error when aggregated", func() {
+		err := errors.Aggregate(errors.Errorf("%w and %w", sentinel1, sentinel2), sentinel1)
+		Expect(err).To(MatchError("[test message 09 and test message 10, test message 09]"))
+	})
+
+	DescribeTable("should only match itself when used as a sentinel",
+		func(format string, args ...any) {
+			sentinel := errors.Errorf(format, args...)
+			Expect(errors.Is(sentinel, sentinel)).To(BeTrue())
+			Expect(stderrors.Is(sentinel, sentinel)).To(BeTrue())
+			Expect(sentinel == sentinel).To(BeTrue())
+			Expect(errors.Is(errors.Errorf(format, args...), sentinel)).To(BeFalse())
+		},
+		Entry("without wrapped errors", "bad value %d", 1),
+		Entry("with a wrapped error", "bad value: %w", sentinel1),
+		Entry("with multiple wrapped errors", "bad values: %w, %w", sentinel1, sentinel2),
+	)
+})
+
+var _ = Describe("WrapfLazy", func() {
+	var calls int
+	var err error
+
+	BeforeEach(func() {
+		calls = 0
+		err = errors.Enrich(errors.New("test message 11"), errors.WrapfLazy("value %s", countingStringer{calls: &calls}))
+	})
+
+	It("should defer formatting until the message is requested", func() {
+		Expect(calls).To(Equal(0))
+		Expect(err).To(MatchError("value counted: test message 11"))
+		Expect(calls).To(Equal(1))
+	})
+
+	It("should preserve the template and arguments", func() {
+		format, args, ok := errors.Template(err)
+		Expect(ok).To(BeTrue())
+		Expect(format).To(Equal("value %s"))
+		Expect(args).To(HaveLen(1))
+		Expect(calls).To(Equal(0))
+	})
+})
+
+var _ = Describe("LogValue", func() {
+	It("should emit the template and arguments as fields", func() {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		logger.Error("failed", "err", errors.Enrich(errors.New("test message 12"), errors.Wrapf("id %d", 42)))
+
+		var entry map[string]any
+		Expect(json.Unmarshal(buf.Bytes(), &entry)).To(Succeed())
+		Expect(entry).To(HaveKeyWithValue("err", map[string]any{
+			"msg":      "id 42: test message 12",
+			"template": "id %d",
+			"args":     []any{float64(42)},
+		}))
+	})
+
+	It("should emit the template of enriched errors with a stack", func() {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		logger.Error("failed", "err", errors.Enrich(errors.New("test message 12"),
+			errors.Wrapf("id %d", 42),
+			errors.Set(DiffDetail("detail")),
+			errors.WithStack(),
+		))
+
+		var entry map[string]any
+		Expect(json.Unmarshal(buf.Bytes(), &entry)).To(Succeed())
+		Expect(entry).To(HaveKeyWithValue("err", map[string]any{
+			"msg":      "id 42: test message 12",
+			"template": "id %d",
+			"args":     []any{float64(42)},
+		}))
+	})
+
+	It("should not emit the template of aggregated errors", func() {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+		err := errors.Enrich(errors.Aggregate(
+			errors.Enrich(errors.New("test message 12"), errors.Wrapf("id %d", 42)),
+			errors.New("test message 11"),
+		), errors.WithStack())
+		logger.Error("failed", "err", err)
+
+		var entry map[string]any
+		Expect(json.Unmarshal(buf.Bytes(), &entry)).To(Succeed())
+		Expect(entry).To(HaveKeyWithValue("err", err.Error()))
+	})
+})
@@ -0,0 +1,118 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package errtree finds the errors wrapped by an error. It is shared by the
+// packages in this module so that error trees are traversed consistently.
+package errtree
+
+import (
+	"errors"
+	"reflect"
+)
+
+// joinType is the type of the errors returned by errors.Join
+var joinType = reflect.TypeOf(errors.Join(errors.New("")))
+
+// Aggregated returns the errors aggregated by err, if err is an aggregate.
+// Aggregates created by this module, errors.Join, go-multierror and multierr
+// are recognised. Errors wrapping multiple errors within a message of their
+// own, such as those created by fmt.Errorf, are not aggregates.
+func Aggregated(err error) ([]error, bool) {
+	switch e := err.(type) {
+	// go-multierror
+	case interface{ WrappedErrors() []error }:
+		return e.WrappedErrors(), true
+	// This module, multierr and similar libraries
+	case interface{ Errors() []error }:
+		return e.Errors(), true
+	}
+
+	if err != nil && reflect.TypeOf(err) == joinType {
+		return err.(interface{ Unwrap() []error }).Unwrap(), true
+	}
+	return nil, false
+}
+
+// Children returns the errors directly wrapped by err
+func Children(err error) []error {
+	// Aggregates are checked first as go-multierror also implements
+	// Unwrap() error, returning a chain that hides the aggregated errors
+	if errs, ok := Aggregated(err); ok {
+		return errs
+	}
+
+	switch unwrapped := err.(type) {
+	case interface{ Unwrap() []error }:
+		return unwrapped.Unwrap()
+	case interface{ Unwrap() error }:
+		if nested := unwrapped.Unwrap(); nested != nil {
+			return []error{nested}
+		}
+	}
+	return nil
+}
+
+// Multiple returns true if err wraps multiple errors, rather than a single
+// error. It is true for aggregates containing a single error.
+func Multiple(err error) bool {
+	if _, ok := Aggregated(err); ok {
+		return true
+	}
+
+	_, ok := err.(interface{ Unwrap() []error })
+	return ok
+}
+
+// Branch returns the error in the path directly below the innermost aggregate.
+// This is the error the end of the path belongs to within the innermost
+// aggregate, if there is no aggregate the first error in the path is returned.
+func Branch(path []error) error {
+	for i := len(path) - 2; i >= 0; i-- {
+		if _, ok := Aggregated(path[i]); ok {
+			return path[i+1]
+		}
+	}
+
+	return path[0]
+}
+
+// PathEnrichment returns the outermost enrichment of type T attached directly
+// to one of the errors in the path. Enrichments within other branches of an
+// aggregate are ignored. Paths are used to build values for sinks that are not
+// privileged, so an enrichment added using errors.Sensitive is never returned.
+func PathEnrichment[T any](path []error) (value T, found bool) {
+	type enrichment interface {
+		Enrichment() any
+		Sensitive() bool
+	}
+
+	for _, err := range path {
+		enriched, ok := err.(enrichment)
+		if !ok {
+			continue
+		}
+
+		if value, ok := enriched.Enrichment().(T); ok {
+			// Sensitive values also hide the values they override
+			if enriched.Sensitive() {
+				break
+			}
+			return value, true
+		}
+	}
+
+	return value, false
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8serrors
+
+import (
+	"unicode/utf8"
+
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/internal/errtree"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// DefaultEventReason is the reason of events recorded for errors that do not
+// have a reason
+const DefaultEventReason = "Error"
+
+// maxEventMessageLength is the maximum length of an event message accepted by
+// the API server.
+const maxEventMessageLength = 1024
+
+type eventType string
+type eventReason string
+
+// NormalEvent enriches an error to indicate events recorded for it should have
+// the Normal type, rather than Warning.
+func NormalEvent() errors.Enricher {
+	return errors.Set(eventType(corev1.EventTypeNormal))
+}
+
+// EventReason enriches an error with the reason used for events recorded for
+// it. The reason should be in UpperCamelCase.
+func EventReason(reason string) errors.Enricher {
+	return errors.Set(eventReason(reason))
+}
+
+// EventOption configures how events are recorded
+type EventOption func(*eventOptions)
+
+type eventOptions struct {
+	perError bool
+}
+
+// EventPerError records an event for each aggregated error, rather than a
+// single event summarising them.
+func EventPerError() EventOption {
+	return func(o *eventOptions) {
+		o.perError = true
+	}
+}
+
+// RecordEvent records an event on the object for the error. Events have the
+// Warning type unless the error has been enriched using NormalEvent. The
+// reason is taken from the EventReason enrichment, falling back to the status
+// reason of the error and then DefaultEventReason. The message is the user
+// facing message of the error, falling back to the error message. Messages are
+// truncated to the maximum length of an event message. Nil errors are ignored.
+func RecordEvent(recorder record.EventRecorder, obj runtime.Object, err error, opts ...EventOption) {
+	if err == nil {
+		return
+	}
+
+	// Apply options
+	var options eventOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	// Record a single summarised event
+	if !options.perError {
+		recorder.Event(obj,
+			string(errors.Get(err, eventType(corev1.EventTypeWarning))),
+			string(errors.Get(err, eventReason(statusEventReason(ReasonForError(err))))),
+			truncateEventMessage(errors.PublicMessage(err, err.Error())),
+		)
+		return
+	}
+
+	// Record an event per aggregated error, each uses the enrichments along its
+	// own path
+	for _, path := range errors.LeafPaths(err) {
+		branch := errtree.Branch(path)
+
+		typ, ok := errtree.PathEnrichment[eventType](path)
+		if !ok {
+			typ = eventType(corev1.EventTypeWarning)
+		}
+
+		reason, ok := errtree.PathEnrichment[eventReason](path)
+		if !ok {
+			status, ok := errtree.PathEnrichment[metav1.StatusReason](path)
+			if !ok {
+				status = ReasonForError(branch)
+			}
+			reason = statusEventReason(status)
+		}
+
+		msg := branch.Error()
+		if public, ok := errtree.PathEnrichment[errors.Message](path); ok {
+			msg = public.String()
+		}
+
+		recorder.Event(obj, string(typ), string(reason), truncateEventMessage(msg))
+	}
+}
+
+func statusEventReason(reason metav1.StatusReason) eventReason {
+	if reason == metav1.StatusReasonUnknown {
+		return DefaultEventReason
+	}
+	return eventReason(reason)
+}
+
+// truncateEventMessage truncates the message to the maximum length of an event
+// message, without splitting a multi-byte character.
+func truncateEventMessage(msg string) string {
+	const ellipsis = "..."
+	if len(msg) <= maxEventMessageLength {
+		return msg
+	}
+
+	end := maxEventMessageLength - len(ellipsis)
+	for end > 0 && !utf8.RuneStart(msg[end]) {
+		end--
+	}
+
+	return msg[:end] + ellipsis
+}
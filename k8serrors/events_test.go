@@ -0,0 +1,101 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8serrors_test
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/k8serrors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+var _ = Describe("RecordEvent", func() {
+	var recorder *record.FakeRecorder
+	var obj *corev1.Pod
+
+	BeforeEach(func() {
+		recorder = record.NewFakeRecorder(10)
+		obj = &corev1.Pod{}
+	})
+
+	events := func() []string {
+		close(recorder.Events)
+		var result []string
+		for event := range recorder.Events {
+			result = append(result, event)
+		}
+		return result
+	}
+
+	It("should ignore nil errors", func() {
+		k8serrors.RecordEvent(recorder, obj, nil)
+		Expect(events()).To(BeEmpty())
+	})
+
+	DescribeTable("should derive the event from the error",
+		func(err error, expected string) {
+			k8serrors.RecordEvent(recorder, obj, err)
+			Expect(events()).To(Equal([]string{expected}))
+		},
+		Entry("plain error", errors.New("test message 01"), "Warning Error test message 01"),
+		Entry("event reason", errors.Enrich(errors.New("test message 01"), k8serrors.EventReason("SyncFailed")), "Warning SyncFailed test message 01"),
+		Entry("normal event", errors.Enrich(errors.New("test message 01"), k8serrors.NormalEvent()), "Normal Error test message 01"),
+		Entry("status reason", errors.Enrich(errors.New("test message 01"), k8serrors.NotFound()), "Warning NotFound test message 01"),
+		Entry("public message", errors.Enrich(errors.New("test message 01"), errors.Public("test message 02")), "Warning Error test message 02"),
+		Entry("aggregate", errors.Aggregate(
+			errors.Enrich(errors.New("test message 01"), k8serrors.EventReason("SyncFailed")),
+			errors.New("test message 02"),
+		), "Warning SyncFailed [test message 01, test message 02]"),
+	)
+
+	It("should record an event per error", func() {
+		k8serrors.RecordEvent(recorder, obj, errors.Enrich(errors.Aggregate(
+			errors.Enrich(errors.New("test message 01"), k8serrors.EventReason("SyncFailed"), errors.Wrap("test message 02")),
+			errors.Enrich(errors.New("test message 03"), k8serrors.Conflict(), k8serrors.NormalEvent()),
+			errors.Enrich(errors.New("test message 04"), errors.Public("test message 05")),
+		), errors.Wrap("test message 06")), k8serrors.EventPerError())
+
+		Expect(events()).To(Equal([]string{
+			"Warning SyncFailed test message 02: test message 01",
+			"Normal Conflict test message 03",
+			"Warning Error test message 05",
+		}))
+	})
+
+	It("should record a single event for errors wrapping multiple errors", func() {
+		k8serrors.RecordEvent(recorder, obj, errors.Errorf("copy %w to %w", errors.New("test message 01"), errors.New("test message 02")), k8serrors.EventPerError())
+
+		Expect(events()).To(Equal([]string{"Warning Error copy test message 01 to test message 02"}))
+	})
+
+	It("should truncate long messages", func() {
+		k8serrors.RecordEvent(recorder, obj, errors.New(strings.Repeat("€", 1024)))
+
+		recorded := events()
+		Expect(recorded).To(HaveLen(1))
+
+		msg := strings.TrimPrefix(recorded[0], "Warning Error ")
+		Expect(len(msg)).To(BeNumerically("<=", 1024))
+		Expect(msg).To(HaveSuffix("..."))
+		Expect(utf8.ValidString(msg)).To(BeTrue())
+	})
+})
@@ -0,0 +1,248 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package k8serrors provides interoperability between enriched errors and the
+// errors used by Kubernetes API machinery.
+package k8serrors
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/internal/errtree"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Reason enriches an error with a Kubernetes status reason.
+func Reason(reason metav1.StatusReason) errors.Enricher {
+	return errors.Set(reason)
+}
+
+// NotFound enriches an error with the NotFound status reason.
+func NotFound() errors.Enricher {
+	return Reason(metav1.StatusReasonNotFound)
+}
+
+// Conflict enriches an error with the Conflict status reason.
+func Conflict() errors.Enricher {
+	return Reason(metav1.StatusReasonConflict)
+}
+
+// AlreadyExists enriches an error with the AlreadyExists status reason.
+func AlreadyExists() errors.Enricher {
+	return Reason(metav1.StatusReasonAlreadyExists)
+}
+
+// Invalid enriches an error with the Invalid status reason.
+func Invalid() errors.Enricher {
+	return Reason(metav1.StatusReasonInvalid)
+}
+
+// Resource identifies the object an error relates to.
+type Resource struct {
+	schema.GroupResource
+
+	// Name is the name of the object
+	Name string
+}
+
+// ForResource enriches an error with the object it relates to, this is used
+// to populate the details of the status returned by ToStatusError.
+func ForResource(resource schema.GroupResource, name string) errors.Enricher {
+	return errors.Set(Resource{GroupResource: resource, Name: name})
+}
+
+// ReasonForError returns the status reason of the error. The reason is taken
+// from the outermost reason enrichment, or Kubernetes API status error, found
+// by unwrapping the error. Errors wrapping multiple errors are not unwrapped,
+// so the reason of one aggregated error does not apply to the others. If there
+// is no reason metav1.StatusReasonUnknown is returned.
+func ReasonForError(err error) metav1.StatusReason {
+	reason := metav1.StatusReasonUnknown
+	primaryChain(err, func(err error) bool {
+		var ok bool
+		reason, ok = errorReason(err)
+		return ok
+	})
+	return reason
+}
+
+// Ignore returns an enricher that drops errors with one of the provided
+// reasons. Aggregates are only dropped if every aggregated error has one of
+// the reasons, taking into account reasons enriching the aggregate itself.
+func Ignore(reasons ...metav1.StatusReason) errors.Enricher {
+	return func(err error) error {
+		for _, path := range errors.LeafPaths(err) {
+			if !slices.Contains(reasons, pathReason(path)) {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// IgnoreNotFound drops errors with the NotFound reason. It can be passed
+// directly to errors.Enrich.
+func IgnoreNotFound(err error) error {
+	return Ignore(metav1.StatusReasonNotFound)(err)
+}
+
+// IgnoreConflict drops errors with the Conflict reason. It can be passed
+// directly to errors.Enrich.
+func IgnoreConflict(err error) error {
+	return Ignore(metav1.StatusReasonConflict)(err)
+}
+
+// IgnoreAlreadyExists drops errors with the AlreadyExists reason. It can be
+// passed directly to errors.Enrich.
+func IgnoreAlreadyExists(err error) error {
+	return Ignore(metav1.StatusReasonAlreadyExists)(err)
+}
+
+// codes maps status reasons to HTTP status codes
+var codes = map[metav1.StatusReason]int32{
+	metav1.StatusReasonUnauthorized:          http.StatusUnauthorized,
+	metav1.StatusReasonForbidden:             http.StatusForbidden,
+	metav1.StatusReasonNotFound:              http.StatusNotFound,
+	metav1.StatusReasonAlreadyExists:         http.StatusConflict,
+	metav1.StatusReasonConflict:              http.StatusConflict,
+	metav1.StatusReasonGone:                  http.StatusGone,
+	metav1.StatusReasonInvalid:               http.StatusUnprocessableEntity,
+	metav1.StatusReasonServerTimeout:         http.StatusInternalServerError,
+	metav1.StatusReasonTimeout:               http.StatusGatewayTimeout,
+	metav1.StatusReasonTooManyRequests:       http.StatusTooManyRequests,
+	metav1.StatusReasonBadRequest:            http.StatusBadRequest,
+	metav1.StatusReasonMethodNotAllowed:      http.StatusMethodNotAllowed,
+	metav1.StatusReasonNotAcceptable:         http.StatusNotAcceptable,
+	metav1.StatusReasonRequestEntityTooLarge: http.StatusRequestEntityTooLarge,
+	metav1.StatusReasonUnsupportedMediaType:  http.StatusUnsupportedMediaType,
+	metav1.StatusReasonInternalError:         http.StatusInternalServerError,
+	metav1.StatusReasonServiceUnavailable:    http.StatusServiceUnavailable,
+}
+
+// ToStatusError converts the error into a Kubernetes API status error. The
+// reason is determined using ReasonForError, errors containing field errors
+// without a reason are treated as Invalid and errors without a reason are
+// treated as an InternalError. The message is the user facing message of the
+// error, falling back to the error message. Field errors are added as causes
+// and the Resource enrichment is used to populate the details. If err is nil,
+// nil is returned.
+func ToStatusError(err error) *apierrors.StatusError {
+	if err == nil {
+		return nil
+	}
+
+	// If the error wraps a status error before any reason enrichment, use the
+	// existing status as is
+	var statusErr *apierrors.StatusError
+	primaryChain(err, func(err error) bool {
+		statusErr, _ = err.(*apierrors.StatusError)
+		_, enriched := errorReason(err)
+		return enriched
+	})
+	if statusErr != nil {
+		return statusErr
+	}
+
+	// Determine the reason
+	groups := errors.GroupFieldErrors(err)
+	reason := ReasonForError(err)
+	if reason == metav1.StatusReasonUnknown {
+		reason = metav1.StatusReasonInternalError
+		if len(groups) > 0 {
+			reason = metav1.StatusReasonInvalid
+		}
+	}
+
+	code, ok := codes[reason]
+	if !ok {
+		code = http.StatusInternalServerError
+	}
+
+	status := metav1.Status{
+		Status:  metav1.StatusFailure,
+		Code:    code,
+		Reason:  reason,
+		Message: errors.PublicMessage(err, err.Error()),
+	}
+
+	// Add the details of the object
+	var details metav1.StatusDetails
+	if resources := errors.All[Resource](err); len(resources) > 0 {
+		details.Group = resources[0].Group
+		details.Kind = resources[0].Resource
+		details.Name = resources[0].Name
+	}
+
+	// Add the field errors as causes
+	for _, group := range groups {
+		for _, err := range group.Errors {
+			details.Causes = append(details.Causes, metav1.StatusCause{
+				Type:    metav1.CauseType(errors.Get[errors.FieldErrorType](err, "")),
+				Message: errors.FieldErrorDetail(err),
+				Field:   group.Path,
+			})
+		}
+	}
+
+	if details.Name != "" || details.Kind != "" || len(details.Causes) > 0 {
+		status.Details = &details
+	}
+
+	return &apierrors.StatusError{ErrStatus: status}
+}
+
+// primaryChain calls fn for each error found by unwrapping err, stopping at
+// errors wrapping multiple errors or when fn returns true.
+func primaryChain(err error, fn func(error) bool) {
+	for depth := 0; err != nil && depth <= errors.MaxDepth; depth++ {
+		if fn(err) || errtree.Multiple(err) {
+			return
+		}
+
+		unwrapped, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return
+		}
+		err = unwrapped.Unwrap()
+	}
+}
+
+// pathReason returns the outermost reason of the errors in the path
+func pathReason(path []error) metav1.StatusReason {
+	for _, err := range path {
+		if reason, ok := errorReason(err); ok {
+			return reason
+		}
+	}
+	return metav1.StatusReasonUnknown
+}
+
+// errorReason returns the reason of the error itself, without unwrapping it
+func errorReason(err error) (metav1.StatusReason, bool) {
+	if enriched, ok := err.(interface{ Enrichment() any }); ok {
+		reason, ok := enriched.Enrichment().(metav1.StatusReason)
+		return reason, ok
+	}
+
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Reason != metav1.StatusReasonUnknown {
+		return status.Status().Reason, true
+	}
+	return metav1.StatusReasonUnknown, false
+}
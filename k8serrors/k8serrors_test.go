@@ -0,0 +1,137 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8serrors_test
+
+import (
+	"net/http"
+
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/k8serrors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var _ = Describe("ReasonForError", func() {
+	var sentinel = errors.New("test message 01")
+	var resource = schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	It("should return the reason enrichment", func() {
+		Expect(k8serrors.ReasonForError(errors.Enrich(sentinel, k8serrors.Conflict()))).To(Equal(metav1.StatusReasonConflict))
+	})
+
+	It("should return the reason of wrapped status errors", func() {
+		err := errors.Enrich(apierrors.NewNotFound(resource, "example"), errors.Wrap("message prefix"))
+		Expect(k8serrors.ReasonForError(err)).To(Equal(metav1.StatusReasonNotFound))
+	})
+
+	It("should return unknown for other errors", func() {
+		Expect(k8serrors.ReasonForError(sentinel)).To(Equal(metav1.StatusReasonUnknown))
+	})
+
+	Context("when filtering errors", func() {
+		It("should drop ignored errors", func() {
+			Expect(errors.Enrich(apierrors.NewNotFound(resource, "example"), k8serrors.IgnoreNotFound, errors.Wrap("message prefix"))).To(Succeed())
+			Expect(errors.Enrich(sentinel, k8serrors.NotFound(), k8serrors.IgnoreNotFound)).To(Succeed())
+			Expect(errors.Enrich(sentinel, k8serrors.Conflict(), k8serrors.IgnoreConflict)).To(Succeed())
+			Expect(errors.Enrich(sentinel, k8serrors.AlreadyExists(), k8serrors.IgnoreAlreadyExists)).To(Succeed())
+		})
+
+		It("should keep other errors", func() {
+			Expect(errors.Enrich(sentinel, k8serrors.Conflict(), k8serrors.IgnoreNotFound)).To(MatchError(sentinel))
+			Expect(errors.Enrich(sentinel, k8serrors.IgnoreConflict)).To(MatchError(sentinel))
+		})
+
+		It("should only drop aggregates where every error is ignored", func() {
+			notFound := apierrors.NewNotFound(resource, "example")
+			Expect(k8serrors.IgnoreNotFound(errors.Aggregate(notFound, sentinel))).To(MatchError(sentinel))
+			Expect(k8serrors.IgnoreNotFound(errors.Aggregate(sentinel, notFound))).To(MatchError(sentinel))
+			Expect(k8serrors.IgnoreNotFound(errors.Aggregate(notFound, errors.Enrich(sentinel, k8serrors.NotFound())))).To(Succeed())
+			Expect(errors.Enrich(errors.Aggregate(notFound, sentinel), k8serrors.NotFound(), k8serrors.IgnoreNotFound)).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("ToStatusError", func() {
+	var sentinel = errors.New("test message 02")
+	var resource = schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	It("should return nil for nil errors", func() {
+		Expect(k8serrors.ToStatusError(nil)).To(BeNil())
+	})
+
+	It("should treat errors without a reason as internal errors", func() {
+		status := k8serrors.ToStatusError(sentinel).Status()
+		Expect(status.Code).To(Equal(int32(http.StatusInternalServerError)))
+		Expect(status.Reason).To(Equal(metav1.StatusReasonInternalError))
+		Expect(status.Message).To(Equal("test message 02"))
+		Expect(status.Details).To(BeNil())
+	})
+
+	It("should not use the reason of a single aggregated error", func() {
+		err := errors.Aggregate(apierrors.NewNotFound(resource, "example"), sentinel)
+		Expect(k8serrors.ReasonForError(err)).To(Equal(metav1.StatusReasonUnknown))
+
+		status := k8serrors.ToStatusError(err).Status()
+		Expect(status.Code).To(Equal(int32(http.StatusInternalServerError)))
+		Expect(status.Reason).To(Equal(metav1.StatusReasonInternalError))
+	})
+
+	It("should build the status from enrichments", func() {
+		err := errors.Enrich(sentinel,
+			k8serrors.AlreadyExists(),
+			k8serrors.ForResource(resource, "example"),
+			errors.Public("Deployment already exists."),
+		)
+
+		statusErr := k8serrors.ToStatusError(err)
+		Expect(apierrors.IsAlreadyExists(statusErr)).To(BeTrue())
+		Expect(statusErr.Status()).To(Equal(metav1.Status{
+			Status:  metav1.StatusFailure,
+			Code:    http.StatusConflict,
+			Reason:  metav1.StatusReasonAlreadyExists,
+			Message: "Deployment already exists.",
+			Details: &metav1.StatusDetails{
+				Group: "apps",
+				Kind:  "deployments",
+				Name:  "example",
+			},
+		}))
+	})
+
+	It("should return wrapped status errors", func() {
+		statusErr := apierrors.NewConflict(resource, "example", sentinel)
+		Expect(k8serrors.ToStatusError(errors.Enrich(statusErr, errors.Wrap("message prefix")))).To(BeIdenticalTo(statusErr))
+	})
+
+	It("should add field errors as causes", func() {
+		err := errors.Aggregate(
+			errors.Invalid(errors.Field("spec", "replicas"), -1, "must be positive"),
+			errors.Required(errors.Field("spec", "selector"), ""),
+		)
+
+		statusErr := k8serrors.ToStatusError(err)
+		Expect(apierrors.IsInvalid(statusErr)).To(BeTrue())
+		Expect(statusErr.Status().Code).To(Equal(int32(http.StatusUnprocessableEntity)))
+		Expect(statusErr.Status().Details.Causes).To(Equal([]metav1.StatusCause{
+			{Type: metav1.CauseTypeFieldValueInvalid, Message: "Invalid value: -1: must be positive", Field: "spec.replicas"},
+			{Type: metav1.CauseTypeFieldValueRequired, Message: "Required value", Field: "spec.selector"},
+		}))
+	})
+})
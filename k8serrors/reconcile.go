@@ -0,0 +1,135 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8serrors
+
+import (
+	"time"
+
+	"github.com/kubespress/errors"
+	"k8s.io/utils/clock"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type requeueAfter time.Duration
+type requeueAt time.Time
+type terminal bool
+type silent bool
+
+// RequeueAfter enriches an error to indicate the reconcile request should be
+// requeued after the provided duration, see ReconcileResult.
+func RequeueAfter(d time.Duration) errors.Enricher {
+	return errors.Set(requeueAfter(d))
+}
+
+// RequeueAt enriches an error to indicate the reconcile request should be
+// requeued at the provided time, see ReconcileResult.
+func RequeueAt(t time.Time) errors.Enricher {
+	return errors.Set(requeueAt(t))
+}
+
+// Terminal enriches an error to indicate it will not be resolved by retrying
+// the reconcile request, see ReconcileResult.
+func Terminal() errors.Enricher {
+	return errors.Set(terminal(true))
+}
+
+// Silent enriches an error to indicate the reconcile request should be retried
+// without the error being reported, see ReconcileResult.
+func Silent() errors.Enricher {
+	return errors.Set(silent(true))
+}
+
+// ReconcileResult converts an error into the result of a reconcile request.
+// Each aggregated error is considered individually, the outermost of the
+// RequeueAfter, RequeueAt, Terminal and Silent enrichments decides how it is
+// handled:
+//
+//   - If any error has none of the enrichments the error is returned as is, so
+//     the request is retried with backoff.
+//   - Otherwise if any error is to be requeued, the request is requeued after
+//     the shortest of the durations.
+//   - Otherwise if any error is terminal, the error is returned as a
+//     reconcile.TerminalError so it is reported but not retried.
+//   - Otherwise the request is requeued with backoff without returning the
+//     error.
+func ReconcileResult(err error) (ctrl.Result, error) {
+	return ReconcileResultWithClock(err, clock.RealClock{})
+}
+
+// ReconcileResultWithClock is the same as ReconcileResult, however the
+// provided clock is used to determine the duration until times passed to
+// RequeueAt.
+func ReconcileResultWithClock(err error, clk clock.PassiveClock) (ctrl.Result, error) {
+	if err == nil {
+		return ctrl.Result{}, nil
+	}
+
+	var requeue, isTerminal, isSilent bool
+	var delay time.Duration
+	for _, path := range errors.LeafPaths(err) {
+		switch action := reconcileAction(path, clk).(type) {
+		case nil:
+			// No enrichment, return the error so it is retried with backoff
+			return ctrl.Result{}, err
+		case time.Duration:
+			if !requeue || action < delay {
+				delay = action
+			}
+			requeue = true
+		case terminal:
+			isTerminal = true
+		case silent:
+			isSilent = true
+		}
+	}
+
+	switch {
+	case requeue && delay > 0:
+		return ctrl.Result{RequeueAfter: delay}, nil
+	case requeue:
+		return ctrl.Result{Requeue: true}, nil
+	case isTerminal:
+		return ctrl.Result{}, reconcile.TerminalError(err)
+	case isSilent:
+		return ctrl.Result{Requeue: true}, nil
+	default:
+		return ctrl.Result{}, err
+	}
+}
+
+// reconcileAction returns the outermost reconcile enrichment in the path,
+// converting requeue enrichments into a duration.
+func reconcileAction(path []error, clk clock.PassiveClock) any {
+	for _, err := range path {
+		enriched, ok := err.(interface{ Enrichment() any })
+		if !ok {
+			continue
+		}
+
+		switch value := enriched.Enrichment().(type) {
+		case requeueAfter:
+			return time.Duration(value)
+		case requeueAt:
+			return time.Time(value).Sub(clk.Now())
+		case terminal, silent:
+			return value
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8serrors_test
+
+import (
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/k8serrors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var _ = Describe("ReconcileResult", func() {
+	var sentinel = errors.New("test message 03")
+	var now = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	var clock *clocktesting.FakePassiveClock
+
+	BeforeEach(func() {
+		clock = clocktesting.NewFakePassiveClock(now)
+	})
+
+	DescribeTable("should derive the result from the error",
+		func(err error, expected ctrl.Result, expectedErr any) {
+			result, resultErr := k8serrors.ReconcileResultWithClock(err, clock)
+			Expect(result).To(Equal(expected))
+			if expectedErr == nil {
+				Expect(resultErr).ToNot(HaveOccurred())
+			} else {
+				Expect(resultErr).To(MatchError(expectedErr))
+			}
+		},
+		Entry("nil error", nil, ctrl.Result{}, nil),
+		Entry("plain error", sentinel, ctrl.Result{}, sentinel),
+		Entry("requeue after", errors.Enrich(sentinel, k8serrors.RequeueAfter(time.Minute)), ctrl.Result{RequeueAfter: time.Minute}, nil),
+		Entry("requeue at", errors.Enrich(sentinel, k8serrors.RequeueAt(now.Add(time.Hour))), ctrl.Result{RequeueAfter: time.Hour}, nil),
+		Entry("requeue in the past", errors.Enrich(sentinel, k8serrors.RequeueAt(now.Add(-time.Hour))), ctrl.Result{Requeue: true}, nil),
+		Entry("silent", errors.Enrich(sentinel, k8serrors.Silent()), ctrl.Result{Requeue: true}, nil),
+		Entry("outermost enrichment", errors.Enrich(sentinel, k8serrors.Terminal(), k8serrors.RequeueAfter(time.Minute)), ctrl.Result{RequeueAfter: time.Minute}, nil),
+		Entry("aggregate with minimum requeue", errors.Aggregate(
+			errors.Enrich(sentinel, k8serrors.RequeueAfter(time.Hour)),
+			errors.Enrich(sentinel, k8serrors.RequeueAt(now.Add(time.Minute))),
+			errors.Enrich(sentinel, k8serrors.Terminal()),
+		), ctrl.Result{RequeueAfter: time.Minute}, nil),
+		Entry("aggregate with plain error", errors.Aggregate(
+			errors.Enrich(sentinel, k8serrors.RequeueAfter(time.Hour)),
+			errors.New("test message 04"),
+		), ctrl.Result{}, "[test message 03, test message 04]"),
+		Entry("go-multierror with minimum requeue", multierror.Append(
+			errors.Enrich(sentinel, k8serrors.RequeueAfter(time.Hour)),
+			errors.Enrich(sentinel, k8serrors.RequeueAfter(time.Minute)),
+		), ctrl.Result{RequeueAfter: time.Minute}, nil),
+		Entry("aggregate with shared enrichment", errors.Enrich(errors.Aggregate(sentinel, errors.New("test message 04")), k8serrors.RequeueAfter(time.Second)), ctrl.Result{RequeueAfter: time.Second}, nil),
+	)
+
+	It("should convert terminal errors", func() {
+		result, err := k8serrors.ReconcileResultWithClock(errors.Enrich(sentinel, k8serrors.Terminal()), clock)
+		Expect(result).To(Equal(ctrl.Result{}))
+		Expect(errors.Is(err, reconcile.TerminalError(nil))).To(BeTrue())
+		Expect(errors.Is(err, sentinel)).To(BeTrue())
+	})
+
+	It("should use the real clock by default", func() {
+		result, err := k8serrors.ReconcileResult(errors.Enrich(sentinel, k8serrors.RequeueAt(time.Now().Add(time.Hour))))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.RequeueAfter).To(BeNumerically("~", time.Hour, time.Minute))
+	})
+})
@@ -0,0 +1,199 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// Catalog provides localised versions of user facing messages.
+type Catalog interface {
+	// Format returns the message localised into the provided language, ok is
+	// false if the catalog does not contain the message for that language.
+	// Catalogs should not fall back to other languages, this is handled by the
+	// Localizer.
+	Format(lang language.Tag, msg Message) (localised string, ok bool)
+}
+
+// MapCatalog is an in-memory Catalog. It maps languages to message keys to
+// format templates, the templates are formatted using the message arguments.
+type MapCatalog map[language.Tag]map[string]string
+
+// Format implements Catalog.
+func (c MapCatalog) Format(lang language.Tag, msg Message) (string, bool) {
+	template, ok := c[lang][msg.Key]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf(template, msg.Args...), true
+}
+
+// LoadJSONCatalog loads a MapCatalog from the JSON files in the root of fsys.
+// Each file is named after the language it contains, for example "de.json",
+// and contains an object mapping message keys to format templates.
+func LoadJSONCatalog(fsys fs.FS) (MapCatalog, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(MapCatalog, len(files))
+	for _, file := range files {
+		// Determine the language from the file name
+		lang, err := language.Parse(strings.TrimSuffix(file, path.Ext(file)))
+		if err != nil {
+			return nil, Enrich(err, Wrapf("invalid language for catalog file %s", file))
+		}
+
+		// Read the file
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, Enrich(err, Wrapf("failed to read catalog file %s", file))
+		}
+
+		// Parse the file
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, Enrich(err, Wrapf("failed to parse catalog file %s", file))
+		}
+
+		result[lang] = messages
+	}
+
+	return result, nil
+}
+
+type textCatalog struct {
+	catalog catalog.Catalog
+}
+
+// TextCatalog returns a Catalog backed by a golang.org/x/text message catalog.
+// Messages are formatted using a golang.org/x/text/message Printer, so
+// features such as plurals are supported.
+func TextCatalog(c catalog.Catalog) Catalog {
+	return textCatalog{catalog: c}
+}
+
+// Format implements Catalog.
+func (c textCatalog) Format(lang language.Tag, msg Message) (string, bool) {
+	// Check the message exists for the language, the printer would otherwise
+	// silently fall back to the key
+	if c.catalog.Context(lang, discardRenderer{}).Execute(msg.Key) != nil {
+		return "", false
+	}
+
+	return message.NewPrinter(lang, message.Catalog(c.catalog)).Sprintf(msg.Key, msg.Args...), true
+}
+
+// discardRenderer discards the rendered message
+type discardRenderer struct{}
+
+func (discardRenderer) Render(string)       {}
+func (discardRenderer) Arg(int) interface{} { return nil }
+
+// Localizer localises the user facing messages of errors using a set of
+// catalogs.
+type Localizer struct {
+	lock     sync.RWMutex
+	fallback []language.Tag
+	catalogs []Catalog
+}
+
+// NewLocalizer returns a Localizer using the provided catalogs. The fallback
+// languages are used when a message is not available in the requested
+// language or its parents.
+func NewLocalizer(fallback []language.Tag, catalogs ...Catalog) *Localizer {
+	return &Localizer{
+		fallback: fallback,
+		catalogs: catalogs,
+	}
+}
+
+// DefaultLocalizer is the Localizer used by Localize, it falls back to English.
+var DefaultLocalizer = NewLocalizer([]language.Tag{language.English})
+
+// AddCatalog adds a catalog to the Localizer. Catalogs are consulted in the
+// order they are added.
+func (l *Localizer) AddCatalog(c Catalog) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.catalogs = append(l.catalogs, c)
+}
+
+// Localize returns the user facing message of the error localised into the
+// provided language. It behaves in the same way as PublicMessage, however each
+// message with a key is looked up in the catalogs, first in the requested
+// language, then in its parents and finally in the fallback languages. Messages
+// without a translation use their default text. If the error does not contain
+// a message the fallback is returned.
+func (l *Localizer) Localize(err error, lang language.Tag, fallback string) string {
+	texts := make([]string, 0, 1)
+	for _, msg := range highestPriority(PublicMessages(err)) {
+		texts = append(texts, l.format(lang, msg))
+	}
+
+	if len(texts) == 0 {
+		return fallback
+	}
+	return strings.Join(texts, "; ")
+}
+
+func (l *Localizer) format(lang language.Tag, msg Message) string {
+	if msg.Key == "" {
+		return msg.String()
+	}
+
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	// Try the requested language and its parents, followed by the fallbacks
+	var candidates []language.Tag
+	for tag := lang; ; tag = tag.Parent() {
+		candidates = append(candidates, tag)
+		if tag.IsRoot() {
+			break
+		}
+	}
+	candidates = append(candidates, l.fallback...)
+
+	for _, tag := range candidates {
+		for _, c := range l.catalogs {
+			if localised, ok := c.Format(tag, msg); ok {
+				return localised
+			}
+		}
+	}
+
+	// No translation, use the default text
+	return msg.String()
+}
+
+// Localize returns the user facing message of the error localised into the
+// provided language using the DefaultLocalizer. If the error does not contain
+// a message the fallback is returned.
+func Localize(err error, lang language.Tag, fallback string) string {
+	return DefaultLocalizer.Localize(err, lang, fallback)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"testing/fstest"
+
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message/catalog"
+)
+
+var _ = Describe("Localizer", func() {
+	var sentinel = errors.New("test message 16")
+	var localizer *errors.Localizer
+
+	quotaExceeded := errors.Enrich(sentinel, errors.PublicKey("quota.exceeded", "Quota of %d exceeded.", 5))
+	notFound := errors.Enrich(sentinel, errors.PublicKey("not.found", "Not found."))
+
+	BeforeEach(func() {
+		localizer = errors.NewLocalizer([]language.Tag{language.English}, errors.MapCatalog{
+			language.German: {
+				"quota.exceeded": "Kontingent von %d überschritten.",
+			},
+			language.English: {
+				"not.found": "Could not be found.",
+			},
+		})
+	})
+
+	It("should return the fallback if there is no message", func() {
+		Expect(localizer.Localize(sentinel, language.German, "fallback")).To(Equal("fallback"))
+	})
+
+	It("should localise the message", func() {
+		Expect(localizer.Localize(quotaExceeded, language.German, "fallback")).To(Equal("Kontingent von 5 überschritten."))
+	})
+
+	It("should fall back to the parent language", func() {
+		Expect(localizer.Localize(quotaExceeded, language.MustParse("de-AT"), "fallback")).To(Equal("Kontingent von 5 überschritten."))
+	})
+
+	It("should fall back to the fallback languages", func() {
+		Expect(localizer.Localize(notFound, language.German, "fallback")).To(Equal("Could not be found."))
+	})
+
+	It("should fall back to the default text", func() {
+		Expect(localizer.Localize(quotaExceeded, language.French, "fallback")).To(Equal("Quota of 5 exceeded."))
+		Expect(localizer.Localize(errors.Enrich(sentinel, errors.Public("Not localisable.")), language.German, "fallback")).To(Equal("Not localisable."))
+	})
+
+	It("should localise each aggregated error", func() {
+		err := errors.Aggregate(quotaExceeded, notFound)
+		Expect(localizer.Localize(err, language.German, "fallback")).To(Equal("Kontingent von 5 überschritten.; Could not be found."))
+	})
+
+	Context("with a JSON catalog", func() {
+		BeforeEach(func() {
+			c, err := errors.LoadJSONCatalog(fstest.MapFS{
+				"fr.json": {Data: []byte(`{"quota.exceeded": "Quota de %d dépassé."}`)},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			localizer.AddCatalog(c)
+		})
+
+		It("should localise the message", func() {
+			Expect(localizer.Localize(quotaExceeded, language.French, "fallback")).To(Equal("Quota de 5 dépassé."))
+		})
+	})
+
+	Context("with an invalid JSON catalog", func() {
+		It("should return an error", func() {
+			_, err := errors.LoadJSONCatalog(fstest.MapFS{
+				"fr.json": {Data: []byte(`{`)},
+			})
+			Expect(err).To(MatchError(ContainSubstring("failed to parse catalog file fr.json")))
+		})
+	})
+
+	Context("with a golang.org/x/text catalog", func() {
+		BeforeEach(func() {
+			builder := catalog.NewBuilder()
+			Expect(builder.SetString(language.Spanish, "quota.exceeded", "Cuota de %d superada.")).To(Succeed())
+			localizer.AddCatalog(errors.TextCatalog(builder))
+		})
+
+		It("should localise the message", func() {
+			Expect(localizer.Localize(quotaExceeded, language.Spanish, "fallback")).To(Equal("Cuota de 5 superada."))
+			Expect(localizer.Localize(quotaExceeded, language.Italian, "fallback")).To(Equal("Quota of 5 exceeded."))
+		})
+	})
+})
@@ -0,0 +1,180 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics provides a Prometheus recorder that counts errors by class,
+// using enrichments and sentinel errors to derive the labels.
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/internal/errtree"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Label is a label on the counter maintained by a Recorder. The value of the
+// label is extracted from each recorded error.
+type Label struct {
+	// Name is the name of the Prometheus label
+	Name string
+
+	// Default is the value used when the error does not contain a value for
+	// the label
+	Default string
+
+	// Value extracts the label value from the path of errors leading from the
+	// recorded error to one of its leaves, the outermost error is first. It
+	// returns false if the path does not contain a value for the label.
+	Value func(path []error) (string, bool)
+}
+
+// Enrichment returns a label whose value is the enrichment of type T, as set
+// using errors.Set. The value is converted to a string using fmt.Sprint. Values
+// added using errors.Sensitive are never used, the default is used instead.
+func Enrichment[T any](name string) Label {
+	return Label{
+		Name: name,
+		Value: func(path []error) (string, bool) {
+			if value, ok := errtree.PathEnrichment[T](path); ok {
+				return fmt.Sprint(value), true
+			}
+			return "", false
+		},
+	}
+}
+
+// Flag returns a label whose value is "true" or "false" depending on the
+// boolean enrichment of type T, mirroring errors.Check.
+func Flag[T ~bool](name string) Label {
+	return Label{
+		Name:    name,
+		Default: strconv.FormatBool(false),
+		Value: func(path []error) (string, bool) {
+			if value, ok := errtree.PathEnrichment[T](path); ok {
+				return strconv.FormatBool(bool(value)), true
+			}
+			return "", false
+		},
+	}
+}
+
+// Sentinel returns a label whose value is the message of the innermost of the
+// provided sentinel errors found in the error chain.
+func Sentinel(name string, sentinels ...error) Label {
+	return Label{
+		Name: name,
+		Value: func(path []error) (string, bool) {
+			// Walk the path innermost first, the innermost match wins
+			for i := len(path) - 1; i >= 0; i-- {
+				for _, sentinel := range sentinels {
+					if isSentinel(path[i], sentinel) {
+						return sentinel.Error(), true
+					}
+				}
+			}
+
+			return "", false
+		},
+	}
+}
+
+// Recorder counts errors using a Prometheus counter vector labelled using the
+// configured labels. It implements prometheus.Collector so it can be
+// registered directly with a registry.
+type Recorder struct {
+	labels  []Label
+	counter *prometheus.CounterVec
+}
+
+// NewRecorder returns a Recorder counting errors using a counter with the
+// provided options and labels.
+func NewRecorder(opts prometheus.CounterOpts, labels ...Label) *Recorder {
+	// Extract the label names
+	names := make([]string, 0, len(labels))
+	for _, label := range labels {
+		names = append(names, label.Name)
+	}
+
+	return &Recorder{
+		labels:  labels,
+		counter: prometheus.NewCounterVec(opts, names),
+	}
+}
+
+// Record increments the counter for the provided error. If the error is an
+// aggregate each of the aggregated errors is counted individually. Nil errors
+// are ignored.
+func (r *Recorder) Record(err error) {
+	if err == nil {
+		return
+	}
+
+	for _, path := range errors.LeafPaths(err) {
+		r.counter.With(r.labelValues(path)).Inc()
+	}
+}
+
+// Enricher returns an errors.Enricher that records the error and returns it
+// unchanged, allowing the recorder to be used within errors.Enrich.
+func (r *Recorder) Enricher() errors.Enricher {
+	return func(err error) error {
+		r.Record(err)
+		return err
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	r.counter.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	r.counter.Collect(ch)
+}
+
+func (r *Recorder) labelValues(path []error) prometheus.Labels {
+	values := make(prometheus.Labels, len(r.labels))
+	for _, label := range r.labels {
+		value, ok := label.Value(path)
+		if !ok {
+			value = label.Default
+		}
+
+		values[label.Name] = value
+	}
+
+	return values
+}
+
+func isSentinel(err, sentinel error) (matches bool) {
+	// Errors can define their own equality
+	if is, ok := err.(interface{ Is(error) bool }); ok && is.Is(sentinel) {
+		return true
+	}
+
+	// Comparing errors whose dynamic type is not comparable panics, treat these
+	// as not matching
+	defer func() {
+		if recover() != nil {
+			matches = false
+		}
+	}()
+
+	return err == sentinel
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics_test
+
+import (
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/metrics"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type Code string
+type Retryable bool
+
+var (
+	errNotFound = errors.New("not found")
+	errConflict = errors.New("conflict")
+)
+
+var _ = Describe("Recorder", func() {
+	var recorder *metrics.Recorder
+
+	BeforeEach(func() {
+		recorder = metrics.NewRecorder(prometheus.CounterOpts{
+			Name: "errors_total",
+			Help: "Number of errors.",
+		},
+			metrics.Enrichment[Code]("code"),
+			metrics.Flag[Retryable]("retryable"),
+			metrics.Sentinel("cause", errNotFound, errConflict),
+		)
+	})
+
+	expect := func(series ...string) {
+		expected := "# HELP errors_total Number of errors.\n# TYPE errors_total counter\n" + strings.Join(series, "\n") + "\n"
+		Expect(testutil.CollectAndCompare(recorder, strings.NewReader(expected), "errors_total")).To(Succeed())
+	}
+
+	Context("with a nil error", func() {
+		BeforeEach(func() {
+			recorder.Record(nil)
+		})
+
+		It("should not record anything", func() {
+			Expect(testutil.CollectAndCount(recorder)).To(Equal(0))
+		})
+	})
+
+	Context("with an enriched error", func() {
+		BeforeEach(func() {
+			recorder.Record(errors.Enrich(errNotFound,
+				errors.Wrap("fetching object"),
+				errors.Set[Code]("E01"),
+				errors.Set[Retryable](true),
+			))
+		})
+
+		It("should label the error using the enrichments and sentinel", func() {
+			expect(`errors_total{cause="not found",code="E01",retryable="true"} 1`)
+		})
+	})
+
+	Context("with sensitive enrichments", func() {
+		BeforeEach(func() {
+			recorder.Record(errors.Enrich(errNotFound,
+				errors.Set[Code]("E01"),
+				errors.Sensitive[Code]("secret code"),
+				errors.Sensitive[Retryable](true),
+			))
+		})
+
+		It("should use the default label values", func() {
+			expect(`errors_total{cause="not found",code="",retryable="false"} 1`)
+		})
+	})
+
+	Context("with an error without enrichments", func() {
+		BeforeEach(func() {
+			recorder.Record(errors.New("test message 01"))
+		})
+
+		It("should use the default label values", func() {
+			expect(`errors_total{cause="",code="",retryable="false"} 1`)
+		})
+	})
+
+	Context("with an aggregate", func() {
+		BeforeEach(func() {
+			recorder.Record(errors.Enrich(
+				errors.Aggregate(
+					errors.Enrich(errNotFound, errors.Set[Code]("E01")),
+					errConflict,
+					errors.Aggregate(errConflict, errors.New("test message 02")),
+				),
+				errors.Set[Retryable](true),
+			))
+		})
+
+		It("should count each aggregated error", func() {
+			expect(
+				`errors_total{cause="",code="",retryable="true"} 1`,
+				`errors_total{cause="conflict",code="",retryable="true"} 2`,
+				`errors_total{cause="not found",code="E01",retryable="true"} 1`,
+			)
+		})
+	})
+
+	Context("with an error wrapping multiple errors", func() {
+		BeforeEach(func() {
+			recorder.Record(errors.Errorf("copy %w to %w", errNotFound, errConflict))
+		})
+
+		It("should count it as a single error", func() {
+			expect(`errors_total{cause="",code="",retryable="false"} 1`)
+		})
+	})
+
+	Context("with a go-multierror error", func() {
+		BeforeEach(func() {
+			recorder.Record(multierror.Append(
+				errors.Enrich(errNotFound, errors.Set[Code]("E01")),
+				errConflict,
+			))
+		})
+
+		It("should count each aggregated error", func() {
+			expect(
+				`errors_total{cause="conflict",code="",retryable="false"} 1`,
+				`errors_total{cause="not found",code="E01",retryable="false"} 1`,
+			)
+		})
+	})
+
+	Context("when used as an enricher", func() {
+		var err error
+
+		BeforeEach(func() {
+			err = errors.Enrich(errConflict, errors.Set[Code]("E02"), recorder.Enricher())
+		})
+
+		It("should record and return the error unchanged", func() {
+			Expect(errors.Is(err, errConflict)).To(BeTrue())
+			Expect(errors.Get[Code](err, "")).To(Equal(Code("E02")))
+			expect(`errors_total{cause="conflict",code="E02",retryable="false"} 1`)
+		})
+	})
+})
@@ -0,0 +1,127 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Message is a user facing message attached to an error. It is kept separate
+// from the internal error message, which is never presented to users.
+type Message struct {
+	// Key identifies the message so that it can be localised. It is empty for
+	// messages that are not localisable.
+	Key string
+
+	// Text is the message presented to users. For messages with arguments it is
+	// used as a format template.
+	Text string
+
+	// Args are the arguments used to format Text
+	Args []any
+
+	// Priority decides which message is presented when an error contains
+	// multiple messages, higher values take precedence
+	Priority int
+}
+
+// String returns the formatted message text
+func (m Message) String() string {
+	if len(m.Args) == 0 {
+		return m.Text
+	}
+	return fmt.Sprintf(m.Text, m.Args...)
+}
+
+// Public enriches an error with a user facing message.
+func Public(msg string) Enricher {
+	return SetPublic(Message{Text: msg})
+}
+
+// PublicKey enriches an error with a localisable user facing message. The key
+// identifies the message for localisation, text is the default message which
+// is formatted using the provided arguments.
+func PublicKey(key, text string, args ...any) Enricher {
+	return SetPublic(Message{Key: key, Text: text, Args: args})
+}
+
+// SetPublic enriches an error with the provided user facing message.
+func SetPublic(msg Message) Enricher {
+	return Set(msg)
+}
+
+// PublicMessages returns the user facing messages within the error. Messages
+// override any messages within the errors they wrap, so each aggregated error
+// contributes at most one message. Duplicate messages are removed.
+func PublicMessages(err error) []Message {
+	var messages []Message
+	seen := map[string]struct{}{}
+
+	_ = Walk(err, func(node WalkNode) error {
+		e, ok := node.Err.(enrichedError[Message])
+		if !ok {
+			return nil
+		}
+
+		// Skip duplicate messages
+		key := e.enrichment.Key + "\x00" + e.enrichment.String()
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			messages = append(messages, e.enrichment)
+		}
+
+		// The message overrides any messages within the error it wraps
+		return SkipSubtree
+	})
+
+	return messages
+}
+
+// PublicMessage returns the user facing message of the error, the internal
+// error message is never returned. If the error contains multiple messages,
+// for example when errors are aggregated, the messages with the highest
+// priority are joined. If the error does not contain a message the fallback is
+// returned.
+func PublicMessage(err error, fallback string) string {
+	texts := make([]string, 0, 1)
+	for _, msg := range highestPriority(PublicMessages(err)) {
+		texts = append(texts, msg.String())
+	}
+
+	if len(texts) == 0 {
+		return fallback
+	}
+	return strings.Join(texts, "; ")
+}
+
+// highestPriority returns the messages sharing the highest priority, in their
+// original order.
+func highestPriority(messages []Message) []Message {
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].Priority > messages[j].Priority
+	})
+
+	for i := range messages {
+		if messages[i].Priority != messages[0].Priority {
+			return messages[:i]
+		}
+	}
+
+	return messages
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func ExamplePublicMessage() {
+	// Enrich error with a user facing message
+	err := errors.Enrich(functionReturningError(), errors.Public("Invalid details entered."))
+
+	// Print the user facing message
+	fmt.Println(errors.PublicMessage(err, "Internal server error."))
+
+	// Output: Invalid details entered.
+}
+
+var _ = Describe("PublicMessage", func() {
+	var sentinel = errors.New("test message 14")
+
+	It("should return the fallback if there is no message", func() {
+		Expect(errors.PublicMessage(nil, "fallback")).To(Equal("fallback"))
+		Expect(errors.PublicMessage(sentinel, "fallback")).To(Equal("fallback"))
+		Expect(errors.PublicMessage(errors.Enrich(sentinel, errors.Wrap("message prefix")), "fallback")).To(Equal("fallback"))
+	})
+
+	It("should return the message", func() {
+		err := errors.Enrich(sentinel, errors.Public("Something went wrong."), errors.Wrap("message prefix"))
+		Expect(errors.PublicMessage(err, "fallback")).To(Equal("Something went wrong."))
+		Expect(err).To(MatchError("message prefix: test message 14"))
+	})
+
+	It("should format localisable messages", func() {
+		err := errors.Enrich(sentinel, errors.PublicKey("quota.exceeded", "Quota of %d exceeded.", 5))
+		Expect(errors.PublicMessage(err, "fallback")).To(Equal("Quota of 5 exceeded."))
+		Expect(errors.PublicMessages(err)).To(Equal([]errors.Message{{Key: "quota.exceeded", Text: "Quota of %d exceeded.", Args: []any{5}}}))
+	})
+
+	It("should prefer the outermost message", func() {
+		err := errors.Enrich(sentinel, errors.Public("Inner message."), errors.Public("Outer message."))
+		Expect(errors.PublicMessage(err, "fallback")).To(Equal("Outer message."))
+	})
+
+	It("should join the messages of aggregated errors", func() {
+		err := errors.Aggregate(
+			errors.Enrich(sentinel, errors.Public("First message.")),
+			errors.New("test message 15"),
+			errors.Enrich(sentinel, errors.Public("Second message.")),
+			errors.Enrich(sentinel, errors.Public("First message.")),
+		)
+		Expect(errors.PublicMessage(err, "fallback")).To(Equal("First message.; Second message."))
+	})
+
+	It("should find messages within go-multierror errors", func() {
+		err := multierror.Append(nil,
+			errors.Enrich(sentinel, errors.Public("First message.")),
+			errors.Enrich(sentinel, errors.Public("Second message.")),
+		)
+		Expect(errors.PublicMessage(err, "fallback")).To(Equal("First message.; Second message."))
+	})
+
+	It("should choose messages by priority", func() {
+		err := errors.Aggregate(
+			errors.Enrich(sentinel, errors.Public("Low priority.")),
+			errors.Enrich(sentinel, errors.SetPublic(errors.Message{Text: "High priority.", Priority: 10})),
+			errors.Enrich(sentinel, errors.Public("Another low priority.")),
+		)
+		Expect(errors.PublicMessage(err, "fallback")).To(Equal("High priority."))
+	})
+})
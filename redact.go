@@ -0,0 +1,190 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// RedactedPlaceholder replaces sensitive values when an error is rendered
+const RedactedPlaceholder = "[REDACTED]"
+
+type secret struct {
+	value any
+}
+
+func (s secret) Format(f fmt.State, verb rune) { io.WriteString(f, RedactedPlaceholder) }
+func (s secret) MarshalJSON() ([]byte, error)  { return []byte(strconv.Quote(RedactedPlaceholder)), nil }
+
+// Secret marks an argument to Errorf, Wrapf or WrapfLazy as sensitive. The
+// value is replaced with RedactedPlaceholder in the error message, it can only
+// be retrieved using Unredacted. For example:
+//
+// errors.Wrapf("invalid token %s", errors.Secret(token))
+func Secret(value any) fmt.Formatter {
+	return secret{value: value}
+}
+
+// Sensitive enriches an error with a specific type, in the same way as Set.
+// The value can be retrieved using Get, however it is replaced with
+// RedactedPlaceholder when rendered by Redacted.
+func Sensitive[T any](value T) Enricher {
+	return func(err error) error {
+		return enrichedError[T]{
+			enrichment: value,
+			sensitive:  true,
+			nested:     err,
+		}
+	}
+}
+
+type redactedError struct {
+	err    error
+	reveal bool
+}
+
+func (err redactedError) Error() string {
+	if err.reveal {
+		return revealedMessage(err.err)
+	}
+	return err.err.Error()
+}
+
+func (err redactedError) Unwrap() error { return err.err }
+func (err redactedError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			io.WriteString(s, err.Error())
+			err.writeEnrichments(s)
+			return
+		}
+		fallthrough
+	case 's':
+		io.WriteString(s, err.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", err.Error())
+	}
+}
+
+func (err redactedError) writeEnrichments(w io.Writer) {
+	type enrichment interface {
+		Enrichment() any
+		Sensitive() bool
+	}
+
+	Visit(err.err, func(nested error) bool {
+		if e, ok := nested.(enrichment); ok {
+			// Scrub sensitive values unless they have been explicitly revealed
+			var value any = RedactedPlaceholder
+			if err.reveal || !e.Sensitive() {
+				value = e.Enrichment()
+			}
+
+			fmt.Fprintf(w, "\n%T: %v", e.Enrichment(), value)
+		}
+		return true
+	})
+}
+
+// Redacted returns a view of the error that is safe to write to logs. When
+// formatted using %+v the enrichments in the chain are rendered after the
+// message, with the values of enrichments added using Sensitive replaced.
+// Arguments marked using Secret are always replaced.
+func Redacted(err error) error {
+	if err == nil {
+		return nil
+	}
+	return redactedError{err: err}
+}
+
+// Unredacted returns a view of the error for privileged sinks. Arguments marked
+// using Secret are revealed in the message and when formatted using %+v the
+// enrichments in the chain are rendered after the message, including the
+// values of enrichments added using Sensitive.
+//
+// Arguments are only revealed within errors created by this package, messages
+// of other errors are used as is.
+func Unredacted(err error) error {
+	if err == nil {
+		return nil
+	}
+	return redactedError{err: err, reveal: true}
+}
+
+// revealedMessage returns the message of the error with any secret arguments
+// revealed.
+func revealedMessage(err error) string {
+	switch e := err.(type) {
+	case redactedError:
+		return revealedMessage(e.err)
+	case wrappedError:
+		msg := e.message()
+		if e.tmpl != nil && len(e.tmpl.args) > 0 {
+			msg = fmt.Sprintf(e.tmpl.format, revealArgs(e.tmpl.args)...)
+		}
+		return msg + ": " + revealedMessage(e.nested)
+	case *formattedError:
+		return fmt.Sprintf(e.plain, revealArgs(e.args)...)
+	case *formattedWrapError:
+		return revealedMessage(e.formattedError)
+	case *formattedWrapErrors:
+		return revealedMessage(e.formattedError)
+	case errorAggregate:
+		return e.message(revealedMessage)
+	case errWithStack:
+		return revealedMessage(e.err)
+	case interface {
+		Enrichment() any
+		Unwrap() error
+	}:
+		return revealedMessage(e.Unwrap())
+	default:
+		return err.Error()
+	}
+}
+
+// revealArgs returns a copy of args with secrets replaced by their values and
+// errors replaced by a formatter that reveals their secrets.
+func revealArgs(args []any) []any {
+	revealed := make([]any, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case secret:
+			revealed[i] = arg.value
+		case error:
+			revealed[i] = revealingFormatter{err: arg}
+		default:
+			revealed[i] = arg
+		}
+	}
+	return revealed
+}
+
+type revealingFormatter struct {
+	err error
+}
+
+func (f revealingFormatter) Format(s fmt.State, verb rune) {
+	msg := revealedMessage(f.err)
+	if verb == 'q' {
+		msg = strconv.Quote(msg)
+	}
+	io.WriteString(s, msg)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type Email string
+type RequestID string
+
+var _ = Describe("Redaction", func() {
+	var sentinel = errors.New("test message 13")
+	var err error
+
+	BeforeEach(func() {
+		err = errors.Aggregate(
+			errors.Enrich(sentinel,
+				errors.Wrapf("invalid token %s for %s", errors.Secret("abc123"), "user"),
+				errors.Sensitive[Email]("user@example.com"),
+				errors.Set[RequestID]("req-1"),
+			),
+			errors.Errorf("password %q rejected: %w", errors.Secret("hunter2"), errors.Enrich(sentinel, errors.WrapfLazy("key %v", errors.Secret(42)))),
+		)
+	})
+
+	It("should mask secrets in the error message", func() {
+		Expect(err).To(MatchError(`[invalid token [REDACTED] for user: test message 13, password [REDACTED] rejected: key [REDACTED]: test message 13]`))
+		Expect(fmt.Sprintf("%v", err)).ToNot(ContainSubstring("abc123"))
+		Expect(fmt.Sprintf("%+v", err)).ToNot(ContainSubstring("hunter2"))
+	})
+
+	It("should mask secrets when logged", func() {
+		var buf bytes.Buffer
+		slog.New(slog.NewJSONHandler(&buf, nil)).Error("failed", "err", errors.Enrich(sentinel, errors.Wrapf("token %s", errors.Secret("abc123"))))
+		Expect(buf.String()).To(ContainSubstring(`"args":["[REDACTED]"]`))
+		Expect(buf.String()).ToNot(ContainSubstring("abc123"))
+	})
+
+	It("should keep sensitive enrichments retrievable", func() {
+		Expect(errors.Get[Email](err, "")).To(Equal(Email("user@example.com")))
+	})
+
+	Context("when redacted", func() {
+		BeforeEach(func() {
+			err = errors.Redacted(err)
+		})
+
+		It("should render the enrichments with sensitive values scrubbed", func() {
+			Expect(fmt.Sprintf("%+v", err)).To(Equal("[invalid token [REDACTED] for user: test message 13, password [REDACTED] rejected: key [REDACTED]: test message 13]\n" +
+				"errors_test.RequestID: req-1\n" +
+				"errors_test.Email: [REDACTED]"))
+			Expect(errors.Is(err, sentinel)).To(BeTrue())
+		})
+	})
+
+	Context("when unredacted", func() {
+		BeforeEach(func() {
+			err = errors.Unredacted(err)
+		})
+
+		It("should reveal the secrets", func() {
+			Expect(err).To(MatchError(`[invalid token abc123 for user: test message 13, password "hunter2" rejected: key 42: test message 13]`))
+			Expect(fmt.Sprintf("%+v", err)).To(HaveSuffix("\nerrors_test.RequestID: req-1\nerrors_test.Email: user@example.com"))
+			Expect(errors.Is(err, sentinel)).To(BeTrue())
+		})
+
+		It("should keep lazy messages without arguments", func() {
+			err := errors.Unredacted(errors.Enrich(sentinel, errors.WrapfLazy("static context")))
+			Expect(err).To(MatchError("static context: test message 13"))
+		})
+	})
+
+	It("should return nil views of nil errors", func() {
+		Expect(errors.Redacted(nil)).To(BeNil())
+		Expect(errors.Unredacted(nil)).To(BeNil())
+	})
+})
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"fmt"
+	"sync"
+)
+
+var sentinels = struct {
+	sync.RWMutex
+	byID    map[string]error
+	byError map[error]string
+}{
+	byID:    map[string]error{},
+	byError: map[error]string{},
+}
+
+// Register registers a sentinel error with a stable ID, allowing errors to be
+// matched by ID when their identity is not available, for example after being
+// sent to another process. Sentinels are typically registered in an init
+// function, alongside their declaration:
+//
+//	var ErrNotFound = errors.New("not found")
+//
+//	func init() {
+//		errors.Register("store.NotFound", ErrNotFound)
+//	}
+//
+// Register panics if the ID is already registered to a different sentinel, if
+// the sentinel is already registered with a different ID or if the sentinel is
+// not comparable. Errors created using New are compared by their message, so
+// sentinels created using New must have unique messages.
+func Register(id string, sentinel error) {
+	if sentinel == nil {
+		panic(fmt.Sprintf("errors: sentinel registered with ID %q is nil", id))
+	}
+
+	sentinels.Lock()
+	defer sentinels.Unlock()
+
+	// Check for collisions
+	if existing, ok := sentinels.byID[id]; ok && !same(existing, sentinel) {
+		panic(fmt.Sprintf("errors: ID %q is already registered to %q", id, existing))
+	}
+	if existing, ok := sentinelID(sentinel); ok && existing != id {
+		panic(fmt.Sprintf("errors: sentinel %q is already registered with ID %q", sentinel, existing))
+	}
+	if !same(sentinel, sentinel) {
+		panic(fmt.Sprintf("errors: sentinel registered with ID %q is not comparable", id))
+	}
+
+	sentinels.byID[id] = sentinel
+	sentinels.byError[sentinel] = id
+}
+
+// SentinelID returns the ID the sentinel error was registered with. Errors are
+// compared by identity, wrapped errors are not unwrapped.
+func SentinelID(err error) (string, bool) {
+	sentinels.RLock()
+	defer sentinels.RUnlock()
+
+	return sentinelID(err)
+}
+
+// Sentinel returns the sentinel error registered with the ID
+func Sentinel(id string) (error, bool) {
+	sentinels.RLock()
+	defer sentinels.RUnlock()
+
+	sentinel, ok := sentinels.byID[id]
+	return sentinel, ok
+}
+
+// sentinelID looks up the ID of the error, the caller must hold the lock
+func sentinelID(err error) (id string, ok bool) {
+	// Looking up an error that is not comparable panics
+	defer func() {
+		if recover() != nil {
+			id, ok = "", false
+		}
+	}()
+
+	id, ok = sentinels.byError[err]
+	return id, ok
+}
+
+// same compares errors by identity, errors that are not comparable are never
+// the same
+func same(a, b error) (result bool) {
+	defer func() {
+		if recover() != nil {
+			result = false
+		}
+	}()
+
+	return a == b
+}
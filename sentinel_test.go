@@ -0,0 +1,83 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// uncomparableError is an error that cannot be compared
+type uncomparableError []string
+
+func (err uncomparableError) Error() string { return "uncomparable" }
+
+var (
+	errRegistered      = errors.New("test message 01")
+	errRegisteredOther = errors.New("test message 02")
+)
+
+func init() {
+	errors.Register("errors_test.Registered", errRegistered)
+	errors.Register("errors_test.RegisteredOther", errRegisteredOther)
+}
+
+var _ = Describe("Register", func() {
+	It("should look up sentinels by ID", func() {
+		sentinel, ok := errors.Sentinel("errors_test.Registered")
+		Expect(ok).To(BeTrue())
+		Expect(sentinel).To(BeIdenticalTo(errRegistered))
+
+		_, ok = errors.Sentinel("errors_test.Missing")
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should look up IDs by sentinel", func() {
+		id, ok := errors.SentinelID(errRegistered)
+		Expect(ok).To(BeTrue())
+		Expect(id).To(Equal("errors_test.Registered"))
+
+		_, ok = errors.SentinelID(errors.Enrich(errRegistered, errors.Wrap("prefix")))
+		Expect(ok).To(BeFalse())
+
+		_, ok = errors.SentinelID(uncomparableError{})
+		Expect(ok).To(BeFalse())
+	})
+
+	It("should allow registering the same sentinel again", func() {
+		Expect(func() { errors.Register("errors_test.Registered", errRegistered) }).ToNot(Panic())
+	})
+
+	It("should detect collisions", func() {
+		Expect(func() { errors.Register("errors_test.Registered", errors.New("test message 03")) }).To(PanicWith(ContainSubstring(`ID "errors_test.Registered" is already registered`)))
+		Expect(func() { errors.Register("errors_test.Other", errRegistered) }).To(PanicWith(ContainSubstring(`already registered with ID "errors_test.Registered"`)))
+
+		// Errors created using New are compared by message
+		Expect(func() { errors.Register("errors_test.Other", errors.New("test message 01")) }).To(Panic())
+	})
+
+	It("should reject invalid sentinels", func() {
+		Expect(func() { errors.Register("errors_test.Nil", nil) }).To(Panic())
+		Expect(func() { errors.Register("errors_test.Uncomparable", uncomparableError{}) }).To(PanicWith(ContainSubstring("not comparable")))
+	})
+
+	It("should fingerprint registered sentinels by ID", func() {
+		Expect(errors.Fingerprint(errRegistered)).ToNot(Equal(errors.Fingerprint(errRegisteredOther)))
+		Expect(errors.Fingerprint(errRegistered, errors.FingerprintSentinel)).ToNot(Equal(errors.Fingerprint(errors.New("test message 03"), errors.FingerprintSentinel)))
+	})
+})
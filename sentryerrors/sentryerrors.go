@@ -0,0 +1,230 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sentryerrors converts errors into Sentry events, preserving the wrap
+// chain, call stacks, enrichments and aggregated errors.
+package sentryerrors
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/internal/errtree"
+)
+
+// Option configures how errors are converted into events
+type Option func(*converter)
+
+// Tag adds the enrichment of type T, as set using errors.Set, to the event as
+// a tag with the provided key. The value is converted to a string using
+// fmt.Sprint. Values added using errors.Sensitive are replaced with
+// errors.RedactedPlaceholder.
+func Tag[T any](key string) Option {
+	return func(c *converter) {
+		c.tags = append(c.tags, func(err error, tags map[string]string) {
+			if value, ok := enrichment[T](err); ok {
+				tags[key] = fmt.Sprint(value)
+			}
+		})
+	}
+}
+
+// Extra adds the enrichment of type T, as set using errors.Set, to the event
+// as extra data with the provided key. Values added using errors.Sensitive are
+// replaced with errors.RedactedPlaceholder.
+func Extra[T any](key string) Option {
+	return func(c *converter) {
+		c.extras = append(c.extras, func(err error, extra map[string]any) {
+			if value, ok := enrichment[T](err); ok {
+				extra[key] = value
+			}
+		})
+	}
+}
+
+// InAppModules marks stack frames from modules with one of the provided
+// prefixes as in-app, all other frames are marked as not in-app. By default
+// Sentry's heuristics are used, which mark all frames outside of the standard
+// library and vendored code as in-app.
+func InAppModules(prefixes ...string) Option {
+	return func(c *converter) {
+		c.inApp = append(c.inApp, prefixes...)
+	}
+}
+
+type converter struct {
+	tags   []func(error, map[string]string)
+	extras []func(error, map[string]any)
+	inApp  []string
+}
+
+// NewEvent converts an error into a Sentry event. Each error in the chain that
+// contributes to the error message is added as an exception, innermost first.
+// Enrichments are not added as exceptions, however stacks added using
+// errors.WithStack are attached to the exception they wrap. Each error within
+// an aggregate is added as a separate exception, grouped under the aggregate.
+func NewEvent(err error, opts ...Option) *sentry.Event {
+	// Apply options
+	var c converter
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	if err == nil {
+		return event
+	}
+
+	// Add the exceptions, they are built outermost first so exception IDs are
+	// assigned starting at the root of the tree, however Sentry expects the
+	// innermost exception first
+	c.addExceptions(event, err)
+	for i, j := 0, len(event.Exception)-1; i < j; i, j = i+1, j-1 {
+		event.Exception[i], event.Exception[j] = event.Exception[j], event.Exception[i]
+	}
+
+	// Add tags and extra data
+	for _, tag := range c.tags {
+		tag(err, event.Tags)
+	}
+	for _, extra := range c.extras {
+		extra(err, event.Extra)
+	}
+
+	return event
+}
+
+// exceptionState is passed from each error to the errors it wraps, it holds
+// the ID of the parent exception and the stack to attach to the next exception
+type exceptionState struct {
+	parent *int
+	stack  *sentry.Stacktrace
+}
+
+func (c *converter) addExceptions(event *sentry.Event, err error) {
+	// states holds the state passed to the errors at each depth
+	states := []exceptionState{{}}
+
+	_ = errors.Walk(err, func(node errors.WalkNode) error {
+		state := states[node.Depth()]
+		states = append(states[:node.Depth()+1], c.addException(event, node.Err, state))
+		return nil
+	})
+}
+
+// addException adds the exception for the error, returning the state passed
+// to the errors it wraps
+func (c *converter) addException(event *sentry.Event, err error, state exceptionState) exceptionState {
+	switch e := err.(type) {
+	// Enrichments do not change the message, skip them
+	case interface{ Enrichment() any }:
+		return state
+
+	// Stacks are attached to the exception they wrap
+	case interface{ Callers() []uintptr }:
+		return exceptionState{parent: state.parent, stack: c.stacktrace(e.Callers())}
+	}
+
+	// Fall back to stack traces from other libraries that Sentry understands
+	stack := state.stack
+	if stack == nil {
+		stack = sentry.ExtractStacktrace(err)
+		if stack != nil {
+			c.setInApp(stack)
+		}
+	}
+
+	// Add the exception
+	id := len(event.Exception)
+	event.Exception = append(event.Exception, sentry.Exception{
+		Type:       reflect.TypeOf(err).String(),
+		Value:      err.Error(),
+		Stacktrace: stack,
+		Mechanism: &sentry.Mechanism{
+			Type:             "generic",
+			ExceptionID:      id,
+			ParentID:         state.parent,
+			IsExceptionGroup: errtree.Multiple(err),
+		},
+	})
+
+	return exceptionState{parent: &id}
+}
+
+// enrichment returns the outermost enrichment of type T, sensitive values are
+// replaced with errors.RedactedPlaceholder as Sentry is not a privileged sink
+func enrichment[T any](err error) (value any, found bool) {
+	type enrichment interface {
+		Enrichment() any
+		Sensitive() bool
+	}
+
+	errors.Visit(err, func(err error) bool {
+		enriched, ok := err.(enrichment)
+		if !ok {
+			return true
+		}
+
+		if v, ok := enriched.Enrichment().(T); ok {
+			value, found = v, true
+			if enriched.Sensitive() {
+				value = errors.RedactedPlaceholder
+			}
+			return false
+		}
+		return true
+	})
+
+	return value, found
+}
+
+func (c *converter) stacktrace(pcs []uintptr) *sentry.Stacktrace {
+	var stack sentry.Stacktrace
+
+	// Sentry expects the oldest frame first, the reverse of runtime.Callers
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		stack.Frames = append([]sentry.Frame{sentry.NewFrame(frame)}, stack.Frames...)
+		if !more {
+			break
+		}
+	}
+
+	c.setInApp(&stack)
+	return &stack
+}
+
+func (c *converter) setInApp(stack *sentry.Stacktrace) {
+	if len(c.inApp) == 0 {
+		return
+	}
+
+	for i := range stack.Frames {
+		stack.Frames[i].InApp = false
+		for _, prefix := range c.inApp {
+			if strings.HasPrefix(stack.Frames[i].Module, prefix) {
+				stack.Frames[i].InApp = true
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sentryerrors_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/kubespress/errors"
+	"github.com/kubespress/errors/sentryerrors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+type Code string
+type RequestID string
+
+var _ = Describe("NewEvent", func() {
+	var sentinel = errors.New("test message 01")
+	var event *sentry.Event
+
+	Context("with a nil error", func() {
+		BeforeEach(func() {
+			event = sentryerrors.NewEvent(nil)
+		})
+
+		It("should return an event without exceptions", func() {
+			Expect(event.Exception).To(BeEmpty())
+		})
+	})
+
+	Context("with a wrapped error", func() {
+		BeforeEach(func() {
+			event = sentryerrors.NewEvent(errors.Enrich(sentinel,
+				errors.Set[Code]("E01"),
+				errors.Wrap("message prefix"),
+				errors.WithStack(),
+				errors.Set[RequestID]("abc"),
+			),
+				sentryerrors.Tag[Code]("code"),
+				sentryerrors.Extra[RequestID]("request_id"),
+				sentryerrors.Extra[int]("missing"),
+			)
+		})
+
+		It("should add the exceptions innermost first", func() {
+			Expect(event.Level).To(Equal(sentry.LevelError))
+			Expect(event.Exception).To(HaveLen(2))
+			Expect(event.Exception[0].Value).To(Equal("test message 01"))
+			Expect(event.Exception[0].Type).To(Equal("errors.errorString"))
+			Expect(event.Exception[0].Mechanism.ExceptionID).To(Equal(1))
+			Expect(event.Exception[0].Mechanism.ParentID).To(Equal(sentry.Pointer(0)))
+			Expect(event.Exception[1].Value).To(Equal("message prefix: test message 01"))
+			Expect(event.Exception[1].Mechanism.ExceptionID).To(Equal(0))
+			Expect(event.Exception[1].Mechanism.ParentID).To(BeNil())
+		})
+
+		It("should attach the stack to the wrapped exception", func() {
+			Expect(event.Exception[0].Stacktrace).To(BeNil())
+			Expect(event.Exception[1].Stacktrace).ToNot(BeNil())
+
+			frames := event.Exception[1].Stacktrace.Frames
+			Expect(frames[len(frames)-1].Module).To(Equal("github.com/kubespress/errors/sentryerrors_test"))
+			Expect(frames[len(frames)-1].AbsPath).To(HaveSuffix("sentryerrors_test.go"))
+			Expect(frames[len(frames)-1].InApp).To(BeTrue())
+		})
+
+		It("should add the registered enrichments", func() {
+			Expect(event.Tags).To(Equal(map[string]string{"code": "E01"}))
+			Expect(event.Extra).To(Equal(map[string]any{"request_id": RequestID("abc")}))
+		})
+	})
+
+	Context("with sensitive enrichments", func() {
+		BeforeEach(func() {
+			event = sentryerrors.NewEvent(errors.Enrich(sentinel,
+				errors.Set[Code]("E01"),
+				errors.Sensitive[Code]("secret code"),
+				errors.Sensitive[RequestID]("secret id"),
+			),
+				sentryerrors.Tag[Code]("code"),
+				sentryerrors.Extra[RequestID]("request_id"),
+			)
+		})
+
+		It("should redact the values", func() {
+			Expect(event.Tags).To(Equal(map[string]string{"code": errors.RedactedPlaceholder}))
+			Expect(event.Extra).To(Equal(map[string]any{"request_id": errors.RedactedPlaceholder}))
+		})
+	})
+
+	Context("with an aggregate", func() {
+		BeforeEach(func() {
+			event = sentryerrors.NewEvent(errors.Aggregate(
+				errors.New("test message 02"),
+				errors.Enrich(sentinel, errors.Wrap("message prefix")),
+			))
+		})
+
+		It("should add an exception for each aggregated error", func() {
+			Expect(event.Exception).To(HaveLen(4))
+
+			Expect(event.Exception[0].Value).To(Equal("test message 01"))
+			Expect(event.Exception[0].Mechanism.ParentID).To(Equal(sentry.Pointer(2)))
+			Expect(event.Exception[1].Value).To(Equal("message prefix: test message 01"))
+			Expect(event.Exception[1].Mechanism.ExceptionID).To(Equal(2))
+			Expect(event.Exception[1].Mechanism.ParentID).To(Equal(sentry.Pointer(0)))
+			Expect(event.Exception[2].Value).To(Equal("test message 02"))
+			Expect(event.Exception[2].Mechanism.ParentID).To(Equal(sentry.Pointer(0)))
+			Expect(event.Exception[3].Value).To(Equal("[test message 02, message prefix: test message 01]"))
+			Expect(event.Exception[3].Mechanism.ExceptionID).To(Equal(0))
+			Expect(event.Exception[3].Mechanism.IsExceptionGroup).To(BeTrue())
+		})
+	})
+
+	Context("with in-app modules", func() {
+		BeforeEach(func() {
+			event = sentryerrors.NewEvent(errors.Enrich(sentinel, errors.WithStack()),
+				sentryerrors.InAppModules("example.com/"),
+			)
+		})
+
+		It("should only mark frames from the modules as in-app", func() {
+			Expect(event.Exception[0].Stacktrace.Frames).ToNot(BeEmpty())
+			for _, frame := range event.Exception[0].Stacktrace.Frames {
+				Expect(frame.InApp).To(BeFalse())
+			}
+		})
+	})
+
+	Context("when sent to Sentry", func() {
+		var requests chan string
+		var server *httptest.Server
+
+		BeforeEach(func() {
+			requests = make(chan string, 1)
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				requests <- string(body)
+			}))
+			DeferCleanup(server.Close)
+
+			client, err := sentry.NewClient(sentry.ClientOptions{
+				Dsn:       strings.Replace(server.URL, "http://", "http://public@", 1) + "/1",
+				Transport: sentry.NewHTTPSyncTransport(),
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			hub := sentry.NewHub(client, sentry.NewScope())
+			hub.CaptureEvent(sentryerrors.NewEvent(errors.Enrich(sentinel, errors.Set[Code]("E02")), sentryerrors.Tag[Code]("code")))
+		})
+
+		It("should deliver the event", func() {
+			var body string
+			Eventually(requests).Should(Receive(&body))
+			Expect(body).To(ContainSubstring(`"value":"test message 01"`))
+			Expect(body).To(ContainSubstring(`"code":"E02"`))
+		})
+	})
+})
@@ -19,6 +19,7 @@ package errors
 import (
 	"fmt"
 	"io"
+	"log/slog"
 	"runtime"
 )
 
@@ -27,8 +28,10 @@ type errWithStack struct {
 	err   error
 }
 
-func (e errWithStack) Error() string { return e.err.Error() }
-func (e errWithStack) Unwrap() error { return e.err }
+func (e errWithStack) Error() string        { return e.err.Error() }
+func (e errWithStack) Unwrap() error        { return e.err }
+func (e errWithStack) Callers() []uintptr   { return e.stack }
+func (e errWithStack) LogValue() slog.Value { return chainLogValue(e) }
 
 func (w errWithStack) Format(s fmt.State, verb rune) {
 	switch verb {
@@ -0,0 +1,329 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors
+
+import (
+	"reflect"
+	"slices"
+
+	"github.com/kubespress/errors/internal/errtree"
+)
+
+// SkipSubtree can be returned by a WalkFunc to skip the errors wrapped by the
+// current error. Returning it from a post-order function has no effect.
+var SkipSubtree = New("skip this subtree")
+
+// SkipAll can be returned by a WalkFunc to stop walking the tree
+var SkipAll = New("skip everything and stop the walk")
+
+// ErrCycle is returned by Walk when an error wraps one of its own ancestors
+var ErrCycle = New("error wraps one of its ancestors")
+
+// ErrMaxDepth is returned by Walk when errors are wrapped deeper than MaxDepth
+var ErrMaxDepth = New("error exceeds the maximum depth")
+
+// MaxDepth is the maximum depth of the error trees traversed by this package,
+// errors wrapped deeper than this are ignored. This protects against stack
+// overflows caused by self-referential errors that cannot be detected as
+// cycles. It should only be changed during initialisation.
+var MaxDepth = 1000
+
+// WalkNode describes an error being visited by Walk and its position in the
+// tree.
+type WalkNode struct {
+	// Err is the error being visited
+	Err error
+	// Ancestors are the errors wrapping Err, starting with the root of the
+	// tree. It is empty when visiting the root.
+	Ancestors []error
+	// Index is the position of each error within the error wrapping it.
+	// Index[i] is the position of Ancestors[i+1], or Err for the last index,
+	// within Ancestors[i]. Errors wrapping a single error have one position,
+	// 0.
+	Index []int
+}
+
+// Depth returns the number of errors wrapping the error
+func (n WalkNode) Depth() int {
+	return len(n.Ancestors)
+}
+
+// Parent returns the error directly wrapping the error, or nil for the root
+func (n WalkNode) Parent() error {
+	if len(n.Ancestors) == 0 {
+		return nil
+	}
+	return n.Ancestors[len(n.Ancestors)-1]
+}
+
+// WalkFunc is called for each error visited by Walk. Returning SkipSubtree
+// skips the errors wrapped by the current error, returning SkipAll stops the
+// walk. Any other error stops the walk and is returned by Walk. The slices of
+// the node are reused, so must be copied if they are retained.
+type WalkFunc func(node WalkNode) error
+
+// Walker walks an error tree, calling Pre before visiting the errors wrapped by
+// each error and Post after. Either function can be nil.
+type Walker struct {
+	Pre  WalkFunc
+	Post WalkFunc
+}
+
+// Walk walks the error tree in depth first order, calling fn for each error.
+// Errors wrapping a single error using Unwrap() error and multiple errors using
+// Unwrap() []error, Errors() []error or WrappedErrors() []error are followed.
+//
+// Errors that wrap one of their own ancestors, and errors deeper than MaxDepth,
+// are not visited, protecting against self-referential errors. The rest of the
+// tree is still walked, then an error matching ErrCycle or ErrMaxDepth is
+// returned describing where the limits were hit.
+func Walk(err error, fn WalkFunc) error {
+	return Walker{Pre: fn}.Walk(err)
+}
+
+// Walk walks the error tree, see the Walk function
+func (w Walker) Walk(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	state := walkState{walker: w, maxDepth: MaxDepth}
+	switch result := state.walk(err); result {
+	case nil, SkipAll:
+		return Aggregate(state.limits...)
+	default:
+		return result
+	}
+}
+
+type walkState struct {
+	walker   Walker
+	node     WalkNode
+	maxDepth int
+	// keys are the identities of the ancestors, used to detect cycles
+	keys []any
+	// limits describe where cycles and the maximum depth were encountered
+	limits []error
+}
+
+func (s *walkState) walk(err error) error {
+	// Check for cycles, errors that cannot be identified are protected by the
+	// maximum depth instead
+	children := errtree.Children(err)
+	key, identified := identity(err, children)
+	if identified {
+		for _, ancestor := range s.keys {
+			if ancestor == key {
+				s.limit(ErrCycle, err)
+				return nil
+			}
+		}
+	}
+
+	// Check the maximum depth
+	if len(s.node.Ancestors) > s.maxDepth {
+		s.limit(ErrMaxDepth, err)
+		return nil
+	}
+
+	s.node.Err = err
+
+	// Call the pre-order function
+	if s.walker.Pre != nil {
+		switch result := s.walker.Pre(s.node); result {
+		case nil:
+		case SkipSubtree:
+			return s.post(err)
+		default:
+			return result
+		}
+	}
+
+	// Visit the wrapped errors
+	if !identified {
+		key = nil
+	}
+
+	for i, child := range children {
+		if child == nil {
+			continue
+		}
+
+		s.keys = append(s.keys, key)
+		s.node.Ancestors = append(s.node.Ancestors, err)
+		s.node.Index = append(s.node.Index, i)
+		result := s.walk(child)
+		s.keys = s.keys[:len(s.keys)-1]
+		s.node.Ancestors = s.node.Ancestors[:len(s.node.Ancestors)-1]
+		s.node.Index = s.node.Index[:len(s.node.Index)-1]
+
+		if result != nil {
+			return result
+		}
+	}
+
+	return s.post(err)
+}
+
+// limit records that a limit was hit when visiting the error
+func (s *walkState) limit(sentinel error, err error) {
+	index := append([]int(nil), s.node.Index...)
+	s.limits = append(s.limits, Enrich(sentinel, Wrapf("%T at %v", err, index)))
+}
+
+func (s *walkState) post(err error) error {
+	if s.walker.Post == nil {
+		return nil
+	}
+
+	s.node.Err = err
+	if result := s.walker.Post(s.node); result != SkipSubtree {
+		return result
+	}
+	return nil
+}
+
+// fastPathDepth is the number of errors searched by following Unwrap() error,
+// without tracking their identities, before searching the rest of the tree
+// using Walk
+const fastPathDepth = 16
+
+// searchChain returns true if fn returns true for an error in the tree. Errors
+// wrapping a single error are searched without allocating, once an error
+// wrapping multiple errors or fastPathDepth is reached the search continues
+// from that error using Walk. As the errors before it form a chain, any cycle
+// through them also passes through that error so is still detected. fn may be
+// called more than once for an error that wraps itself.
+func searchChain(err error, fn func(error) bool) bool {
+	for depth := 0; err != nil && depth <= MaxDepth; depth++ {
+		if depth == fastPathDepth || errtree.Multiple(err) {
+			var ancestors [fastPathDepth]any
+			return searchTree(err, MaxDepth-depth, ancestors[:0], fn)
+		}
+
+		if fn(err) {
+			return true
+		}
+
+		unwrapped, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return false
+		}
+		err = unwrapped.Unwrap()
+	}
+	return false
+}
+
+// searchTree returns true if fn returns true for an error in the tree. Cycles
+// and the maximum depth are handled in the same way as Walk, ancestors are the
+// identities of the errors wrapping err. Unlike Walk, fn does not escape so
+// callers can search without allocating.
+func searchTree(err error, maxDepth int, ancestors []any, fn func(error) bool) bool {
+	children := errtree.Children(err)
+	key, identified := identity(err, children)
+	if identified && slices.Contains(ancestors, key) || len(ancestors) > maxDepth {
+		return false
+	}
+
+	if fn(err) {
+		return true
+	}
+
+	for _, child := range children {
+		if child != nil && searchTree(child, maxDepth, append(ancestors, key), fn) {
+			return true
+		}
+	}
+	return false
+}
+
+// LeafPaths returns the path from err to each error at the leaves of the error
+// tree, found using Walk. Each path starts with err and ends with an error that
+// does not wrap any other errors, or whose wrapped errors were not visited as
+// a traversal limit was hit. Only aggregates are split into multiple paths,
+// errors wrapping multiple errors within a message of their own, such as those
+// created by Errorf, are a single error so end their path. The paths do not
+// share backing arrays.
+func LeafPaths(err error) [][]error {
+	var paths [][]error
+
+	// entered is the number of paths found when each ancestor was entered,
+	// errors that add no paths below them are leaves
+	var entered []int
+	_ = Walker{
+		Pre: func(node WalkNode) error {
+			entered = append(entered, len(paths))
+			if _, ok := errtree.Aggregated(node.Err); !ok && errtree.Multiple(node.Err) {
+				return SkipSubtree
+			}
+			return nil
+		},
+		Post: func(node WalkNode) error {
+			leaf := len(paths) == entered[len(entered)-1]
+			entered = entered[:len(entered)-1]
+
+			if leaf {
+				path := make([]error, 0, len(node.Ancestors)+1)
+				paths = append(paths, append(append(path, node.Ancestors...), node.Err))
+			}
+			return nil
+		},
+	}.Walk(err)
+
+	return paths
+}
+
+// reference identifies an error by the address of the slice or map backing it
+type reference struct {
+	typ     reflect.Type
+	pointer uintptr
+	length  int
+}
+
+// identity returns a value identifying the error that can be used as a map
+// key. Comparable errors are their own identity, errors backed by a slice or
+// map are identified by their address. Uncomparable errors wrapping multiple
+// errors, such as structs holding a slice of errors, are identified by the
+// address of the slice of children. Other errors cannot be identified.
+func identity(err error, children []error) (key any, ok bool) {
+	value := reflect.ValueOf(err)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return reference{typ: value.Type(), pointer: value.Pointer(), length: value.Len()}, true
+	}
+
+	if !value.Type().Comparable() {
+		if len(children) == 0 || !errtree.Multiple(err) {
+			return nil, false
+		}
+		return reference{typ: value.Type(), pointer: reflect.ValueOf(children).Pointer(), length: len(children)}, true
+	}
+
+	// Comparable types can still panic if they contain interface values holding
+	// uncomparable values, and errors containing NaN are not equal to
+	// themselves, neither can be used as a map key
+	defer func() {
+		if recover() != nil {
+			key, ok = nil, false
+		}
+	}()
+
+	if err != err {
+		return nil, false
+	}
+	return err, true
+}
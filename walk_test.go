@@ -0,0 +1,288 @@
+/*
+Copyright 2023 Kubespress Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errors_test
+
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/kubespress/errors"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// selfReferentialError wraps itself
+type selfReferentialError struct{}
+
+func (err *selfReferentialError) Error() string { return "self referential" }
+func (err *selfReferentialError) Unwrap() error { return err }
+
+// infiniteError wraps a new error each time it is unwrapped, as it is not
+// comparable it can only be stopped by the maximum depth
+type infiniteError struct {
+	depth []int
+}
+
+func (err infiniteError) Error() string { return "infinite" }
+func (err infiniteError) Unwrap() error { return infiniteError{depth: append(err.depth, 0)} }
+
+// recursiveAggregate is an aggregate that can contain itself
+type recursiveAggregate []error
+
+func (err recursiveAggregate) Error() string   { return "recursive aggregate" }
+func (err recursiveAggregate) Errors() []error { return err }
+
+// structAggregate is an uncomparable aggregate that can contain itself
+type structAggregate struct {
+	errs []error
+}
+
+func (err structAggregate) Error() string   { return "struct aggregate" }
+func (err structAggregate) Errors() []error { return err.errs }
+
+var _ = Describe("Walk", func() {
+	var sentinel1 = errors.New("test message 21")
+	var sentinel2 = errors.New("test message 22")
+
+	// describe renders each node visited, indented by depth
+	describe := func(prefix string, lines *[]string) errors.WalkFunc {
+		return func(node errors.WalkNode) error {
+			*lines = append(*lines, fmt.Sprintf("%s%s%v %s", strings.Repeat("  ", node.Depth()), prefix, node.Index, node.Err))
+			return nil
+		}
+	}
+
+	It("should walk the tree in pre and post order", func() {
+		err := errors.Enrich(errors.Aggregate(sentinel1, errors.Enrich(sentinel2, errors.Wrap("prefix"))), errors.Wrap("outer"))
+
+		var lines []string
+		Expect(errors.Walker{Pre: describe("pre ", &lines), Post: describe("post ", &lines)}.Walk(err)).To(Succeed())
+		Expect(lines).To(Equal([]string{
+			"pre [] outer: [test message 21, prefix: test message 22]",
+			"  pre [0] [test message 21, prefix: test message 22]",
+			"    pre [0 0] test message 21",
+			"    post [0 0] test message 21",
+			"    pre [0 1] prefix: test message 22",
+			"      pre [0 1 0] test message 22",
+			"      post [0 1 0] test message 22",
+			"    post [0 1] prefix: test message 22",
+			"  post [0] [test message 21, prefix: test message 22]",
+			"post [] outer: [test message 21, prefix: test message 22]",
+		}))
+	})
+
+	It("should provide the ancestors and parent", func() {
+		wrapped := errors.Enrich(sentinel1, errors.Wrap("prefix"))
+		aggregate := errors.Aggregate(sentinel2, wrapped)
+
+		Expect(errors.Walk(aggregate, func(node errors.WalkNode) error {
+			if node.Err == sentinel1 {
+				Expect(node.Ancestors).To(Equal([]error{aggregate, wrapped}))
+				Expect(node.Parent()).To(Equal(wrapped))
+				Expect(node.Depth()).To(Equal(2))
+				return errors.SkipAll
+			}
+			return nil
+		})).To(Succeed())
+
+		Expect(errors.Walk(aggregate, func(node errors.WalkNode) error {
+			Expect(node.Parent()).To(BeNil())
+			return errors.SkipAll
+		})).To(Succeed())
+	})
+
+	It("should skip subtrees", func() {
+		err := errors.Aggregate(errors.Enrich(sentinel1, errors.Wrap("prefix")), sentinel2)
+
+		var lines []string
+		visit := describe("", &lines)
+		Expect(errors.Walk(err, func(node errors.WalkNode) error {
+			Expect(visit(node)).To(Succeed())
+			if node.Depth() == 1 {
+				return errors.SkipSubtree
+			}
+			return nil
+		})).To(Succeed())
+		Expect(lines).To(HaveLen(3))
+	})
+
+	It("should stop the walk", func() {
+		count := 0
+		Expect(errors.Walk(errors.Aggregate(sentinel1, sentinel2), func(node errors.WalkNode) error {
+			count++
+			if node.Err == sentinel1 {
+				return errors.SkipAll
+			}
+			return nil
+		})).To(Succeed())
+		Expect(count).To(Equal(2))
+	})
+
+	It("should return errors from the walk function", func() {
+		failure := errors.New("test message 23")
+		Expect(errors.Walk(sentinel1, func(errors.WalkNode) error { return failure })).To(Equal(failure))
+	})
+
+	It("should follow errors with an Errors method", func() {
+		var lines []string
+		Expect(errors.Walk(recursiveAggregate{sentinel1, sentinel2}, describe("", &lines))).To(Succeed())
+		Expect(lines).To(Equal([]string{"[] recursive aggregate", "  [0] test message 21", "  [1] test message 22"}))
+	})
+
+	It("should not follow cycles", func() {
+		count := 0
+		err := errors.Walk(errors.Enrich(&selfReferentialError{}, errors.Wrap("prefix")), func(errors.WalkNode) error {
+			count++
+			return nil
+		})
+		Expect(count).To(Equal(2))
+		Expect(errors.Is(err, errors.ErrCycle)).To(BeTrue())
+		Expect(err).To(MatchError("*errors_test.selfReferentialError at [0 0]: error wraps one of its ancestors"))
+
+		aggregate := recursiveAggregate{sentinel1, nil, sentinel2}
+		aggregate[1] = aggregate
+
+		var lines []string
+		err = errors.Walk(aggregate, describe("", &lines))
+		Expect(errors.Is(err, errors.ErrCycle)).To(BeTrue())
+		Expect(lines).To(Equal([]string{"[] recursive aggregate", "  [0] test message 21", "  [2] test message 22"}))
+	})
+
+	It("should not follow cycles through uncomparable aggregates", func() {
+		DeferCleanup(func(depth int) { errors.MaxDepth = depth }, errors.MaxDepth)
+		errors.MaxDepth = 100
+
+		// Without identifying the aggregate each visit would double the number
+		// of nodes until the maximum depth is reached
+		aggregate := structAggregate{errs: []error{nil, sentinel1, nil}}
+		aggregate.errs[0], aggregate.errs[2] = aggregate, aggregate
+
+		var lines []string
+		err := errors.Walk(aggregate, describe("", &lines))
+		Expect(errors.Is(err, errors.ErrCycle)).To(BeTrue())
+		Expect(lines).To(Equal([]string{"[] struct aggregate", "  [1] test message 21"}))
+	})
+
+	It("should limit the depth", func() {
+		DeferCleanup(func(depth int) { errors.MaxDepth = depth }, errors.MaxDepth)
+		errors.MaxDepth = 10
+
+		count := 0
+		err := errors.Walk(infiniteError{}, func(errors.WalkNode) error {
+			count++
+			return nil
+		})
+		Expect(count).To(Equal(11))
+		Expect(errors.Is(err, errors.ErrMaxDepth)).To(BeTrue())
+	})
+
+	It("should return errors from the walk function rather than limits", func() {
+		failure := errors.New("test message 23")
+		Expect(errors.Walk(errors.Aggregate(&selfReferentialError{}, sentinel1), func(node errors.WalkNode) error {
+			if node.Err == sentinel1 {
+				return failure
+			}
+			return nil
+		})).To(Equal(failure))
+	})
+
+	It("should visit repeated errors that are not cycles", func() {
+		count := 0
+		Expect(errors.Walk(errors.Aggregate(sentinel1, sentinel1), func(errors.WalkNode) error {
+			count++
+			return nil
+		})).To(Succeed())
+		Expect(count).To(Equal(3))
+	})
+})
+
+var _ = Describe("LeafPaths", func() {
+	var sentinel1 = errors.New("test message 25")
+	var sentinel2 = errors.New("test message 26")
+
+	It("should return nil for nil errors", func() {
+		Expect(errors.LeafPaths(nil)).To(BeNil())
+	})
+
+	It("should return the path to each leaf", func() {
+		inner := errors.Enrich(sentinel2, errors.Wrap("prefix"))
+		aggregate := errors.Aggregate(sentinel1, inner)
+		err := errors.Enrich(aggregate, errors.Wrap("outer"))
+
+		Expect(errors.LeafPaths(err)).To(Equal([][]error{
+			{err, aggregate, sentinel1},
+			{err, aggregate, inner, sentinel2},
+		}))
+	})
+
+	It("should only split paths at aggregates", func() {
+		formatted := errors.Errorf("copy %w to %w", sentinel1, sentinel2)
+		err := errors.Aggregate(formatted, stderrors.Join(sentinel1, sentinel2))
+		Expect(errors.LeafPaths(err)).To(Equal([][]error{{err, formatted}, {err, sentinel1}, {err, sentinel2}}))
+	})
+
+	It("should end paths at errors wrapping their ancestors", func() {
+		self := &selfReferentialError{}
+		err := errors.Aggregate(self, sentinel1)
+		Expect(errors.LeafPaths(err)).To(Equal([][]error{{err, self}, {err, sentinel1}}))
+	})
+})
+
+var _ = Describe("Traversal limits", func() {
+	var sentinel = errors.New("test message 24")
+
+	// Each traversal must terminate for self-referential errors
+	traversals := map[string]func(err error){
+		"Is":            func(err error) { errors.Is(err, sentinel) },
+		"As":            func(err error) { var target *thirdPartyError; errors.As(err, &target) },
+		"Get":           func(err error) { errors.Get[DiffDetail](err, "") },
+		"Check":         func(err error) { errors.Check[DiffFlag](err) },
+		"All":           func(err error) { errors.All[DiffDetail](err) },
+		"Visit":         func(err error) { errors.Visit(err, func(error) bool { return true }) },
+		"Classify":      func(err error) { errors.Classify(err) },
+		"Fingerprint":   func(err error) { errors.Fingerprint(err) },
+		"Diff":          func(err error) { errors.Diff(err, sentinel) },
+		"Error":         func(err error) { _ = err.Error() },
+		"PublicMessage": func(err error) { errors.PublicMessage(err, "") },
+	}
+
+	for name, traverse := range traversals {
+		name, traverse := name, traverse
+		It("should protect "+name+" against self-referential errors", func() {
+			DeferCleanup(func(depth int) { errors.MaxDepth = depth }, errors.MaxDepth)
+			errors.MaxDepth = 100
+
+			aggregate := recursiveAggregate{sentinel, nil}
+			aggregate[1] = aggregate
+
+			traverse(errors.Enrich(&selfReferentialError{}, errors.Wrap("prefix")))
+			traverse(errors.Aggregate(infiniteError{}, sentinel))
+			traverse(errors.Aggregate(aggregate, sentinel))
+
+			self := structAggregate{errs: []error{nil, sentinel, nil}}
+			self.errs[0], self.errs[2] = self, self
+			traverse(errors.Aggregate(self, sentinel))
+		})
+	}
+
+	It("should find errors beside cycles", func() {
+		err := errors.Aggregate(&selfReferentialError{}, errors.Enrich(sentinel, errors.Set[DiffDetail]("detail")))
+		Expect(errors.Is(err, sentinel)).To(BeTrue())
+		Expect(errors.Get[DiffDetail](err, "")).To(Equal(DiffDetail("detail")))
+	})
+})